@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry is stored next to a cached payload
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+func (c *CSGOAPIClient) cachePath(e Endpoint) string {
+	return filepath.Join(c.cacheDir, string(e))
+}
+
+func (c *CSGOAPIClient) entryPath(e Endpoint) string {
+	return c.cachePath(e) + ".meta.json"
+}
+
+// cached returns the validators of the cached payload of the endpoint
+// entries of another base url or without a payload on disk are ignored
+func (c *CSGOAPIClient) cached(e Endpoint, url string) (cacheEntry, bool) {
+	if c.cacheDir == "" {
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(c.entryPath(e))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return cacheEntry{}, false
+	}
+	if _, err := os.Stat(c.cachePath(e)); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes the payload of a response and its validators to the cache
+// the payload is written to a temporary file first so an aborted write never replaces a valid one
+func (c *CSGOAPIClient) store(e Endpoint, url string, header http.Header, payload []byte) error {
+	if err := os.MkdirAll(c.cacheDir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.cacheDir, string(e)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.cachePath(e)); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.entryPath(e), data, 0o644)
+}
@@ -1,22 +1,100 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
-type CSGOAPIClient struct{}
+// DefaultBaseURL is the english api of ByMykel/CSGO-API
+const DefaultBaseURL = "https://bymykel.github.io/CSGO-API/api/en"
 
-func NewCSGOAPIClient() *CSGOAPIClient {
-	return &CSGOAPIClient{}
+// CSGOAPIClient is the Source fetching the api over HTTP
+type CSGOAPIClient struct {
+	digests
+	baseURL    string
+	cacheDir   string
+	httpClient *http.Client
+	retry      RetryPolicy
 }
 
-// getRequest Fetches the requested URL and unmarshal the response to the passed interface
+type ClientOption func(*CSGOAPIClient)
+
+// WithBaseURL fetches from a mirror of the api, ex. a local http server
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *CSGOAPIClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithCacheDir keeps the payloads with their ETag and Last-Modified headers in dir
+// so unchanged endpoints are answered with a 304 and read from disk
+func WithCacheDir(dir string) ClientOption {
+	return func(c *CSGOAPIClient) {
+		c.cacheDir = dir
+	}
+}
+
+// WithHTTPClient replaces the default client, which times out after DefaultTimeout
+func WithHTTPClient(h *http.Client) ClientOption {
+	return func(c *CSGOAPIClient) {
+		c.httpClient = h
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(c *CSGOAPIClient) {
+		c.retry = p
+	}
+}
+
+func NewCSGOAPIClient(opts ...ClientOption) *CSGOAPIClient {
+	c := &CSGOAPIClient{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Localized returns a client for the api of another language, the payloads are cached in a subdirectory
+func (c *CSGOAPIClient) Localized(locale string) (Source, error) {
+	i := strings.LastIndex(c.baseURL, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("can't derive the %s api from %s", locale, c.baseURL)
+	}
+
+	localized := &CSGOAPIClient{
+		baseURL:    c.baseURL[:i] + "/" + locale,
+		httpClient: c.httpClient,
+		retry:      c.retry,
+	}
+	if c.cacheDir != "" {
+		localized.cacheDir = filepath.Join(c.cacheDir, locale)
+	}
+	return localized, nil
+}
+
+func (c *CSGOAPIClient) url(e Endpoint) string {
+	return c.baseURL + "/" + string(e)
+}
+
+// getRequest Fetches the endpoint and unmarshal the response to the passed interface
 // T is the type of the response
-func getRequest[T any](url string) (T, error) {
-	//Fetch the requested URL
-	res, err := http.Get(url)
+func getRequest[T any](ctx context.Context, c *CSGOAPIClient, e Endpoint) (T, error) {
+	//Fetch the endpoint, either from the network or the cache
+	body, err := c.fetch(ctx, e)
 
 	//set zeroValue to return in case of error
 	//this is needed because we are returning a generic type
@@ -24,20 +102,97 @@ func getRequest[T any](url string) (T, error) {
 	if err != nil {
 		return zeroValue, err
 	}
-	defer res.Body.Close()
+	defer body.Close()
+
+	//unmarshal to the passed interface
+	return decode[T](&c.digests, e, body)
+}
+
+// transientError is a failed attempt that may succeed when retried
+type transientError struct {
+	err error
+	res *http.Response // response of the attempt, if any, for its Retry-After header
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+
+func (e *transientError) Unwrap() error { return e.err }
+
+// fetch returns the payload of the endpoint, retrying transient failures according to the retry policy
+func (c *CSGOAPIClient) fetch(ctx context.Context, e Endpoint) (io.ReadCloser, error) {
+	attempts := max(c.retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		body, err := c.attempt(ctx, e)
+		if err == nil {
+			return body, nil
+		}
+
+		var transient *transientError
+		if !errors.As(err, &transient) || ctx.Err() != nil {
+			return nil, err
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("fetching %s failed after %d attempts: %w", e, attempt, err)
+		}
+
+		if err := sleep(ctx, c.retry.delay(attempt, transient.res)); err != nil {
+			return nil, err
+		}
+	}
+}
 
-	//return error if the status code is not 200 with zero value
-	if res.StatusCode != http.StatusOK {
-		return zeroValue, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+// attempt requests the endpoint once, conditional on the cached version if there is one
+func (c *CSGOAPIClient) attempt(ctx context.Context, e Endpoint) (io.ReadCloser, error) {
+	url := c.url(e)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	//unmarshal to the passed interface
-	var response T
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return zeroValue, err
+	cached, ok := c.cached(e, url)
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
 	}
 
-	return response, nil
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &transientError{err: err}
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && ok:
+		res.Body.Close()
+		return os.Open(c.cachePath(e))
+	case res.StatusCode == http.StatusOK:
+		// the body is read within the attempt, a connection dropped halfway is worth another attempt
+		payload, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, &transientError{err: fmt.Errorf("reading %s: %w", e, err)}
+		}
+		if c.cacheDir != "" {
+			// failing to write the cache is a local fault, retrying the request won't fix it
+			if err := c.store(e, url, res.Header, payload); err != nil {
+				return nil, fmt.Errorf("caching %s: %w", e, err)
+			}
+		}
+		return io.NopCloser(bytes.NewReader(payload)), nil
+	case retryable(res.StatusCode):
+		res.Body.Close()
+		return nil, &transientError{
+			err: fmt.Errorf("unexpected status code: %d", res.StatusCode),
+			res: res,
+		}
+	default:
+		//return error if the status code is not 200
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
 }
 
 type NameID struct {
@@ -75,13 +230,15 @@ type Sticker struct {
 	Crate           []Crate `json:"crates"`
 	TournamentEvent string  `json:"tournament_event"`
 	TournamentTeam  string  `json:"tournament_team"`
+	Type            string  `json:"type"`   // ex. Autograph, Team or Event, missing on older snapshots
+	Effect          string  `json:"effect"` // ex. Holo or Foil, missing on older snapshots
 }
 
 type CollectionResp NameIDImage
 
 // Fetches the stickers
-func (c *CSGOAPIClient) FetchStickers() (StickerResponse, error) {
-	stickers, err := getRequest[StickerResponse]("https://bymykel.github.io/CSGO-API/api/en/stickers.json")
+func (c *CSGOAPIClient) FetchStickers(ctx context.Context) (StickerResponse, error) {
+	stickers, err := getRequest[StickerResponse](ctx, c, StickersEndpoint)
 	if err != nil {
 		return StickerResponse{}, err
 	}
@@ -97,8 +254,8 @@ type Agent struct {
 	Team        Team             `json:"team"`
 }
 
-func (c *CSGOAPIClient) FetchAgents() (AgentResponse, error) {
-	agents, err := getRequest[AgentResponse]("https://bymykel.github.io/CSGO-API/api/en/agents.json")
+func (c *CSGOAPIClient) FetchAgents(ctx context.Context) (AgentResponse, error) {
+	agents, err := getRequest[AgentResponse](ctx, c, AgentsEndpoint)
 	if err != nil {
 		return AgentResponse{}, err
 	}
@@ -112,8 +269,8 @@ type Patch struct {
 	BaseItemInstance
 }
 
-func (c *CSGOAPIClient) FetchPatches() (PatchResponse, error) {
-	patches, err := getRequest[PatchResponse]("https://bymykel.github.io/CSGO-API/api/en/patches.json")
+func (c *CSGOAPIClient) FetchPatches(ctx context.Context) (PatchResponse, error) {
+	patches, err := getRequest[PatchResponse](ctx, c, PatchesEndpoint)
 	if err != nil {
 		return PatchResponse{}, err
 	}
@@ -128,8 +285,8 @@ type Charm struct {
 	Collections []CollectionResp `json:"collections"`
 }
 
-func (c *CSGOAPIClient) FetchCharms() (CharmResponse, error) {
-	charms, err := getRequest[CharmResponse]("https://bymykel.github.io/CSGO-API/api/en/keychains.json")
+func (c *CSGOAPIClient) FetchCharms(ctx context.Context) (CharmResponse, error) {
+	charms, err := getRequest[CharmResponse](ctx, c, CharmsEndpoint)
 	if err != nil {
 		return CharmResponse{}, err
 	}
@@ -146,8 +303,8 @@ type Cases struct {
 	ContainsRare []BaseItemInstance `json:"contains_rare"`
 }
 
-func (c *CSGOAPIClient) FetchCases() (CaseResponse, error) {
-	cases, err := getRequest[CaseResponse]("https://bymykel.github.io/CSGO-API/api/en/crates.json")
+func (c *CSGOAPIClient) FetchCases(ctx context.Context) (CaseResponse, error) {
+	cases, err := getRequest[CaseResponse](ctx, c, CasesEndpoint)
 	if err != nil {
 		return CaseResponse{}, err
 	}
@@ -177,6 +334,7 @@ type Skin struct {
 	Stattrak    bool             `json:"stattrak"`
 	Souvenir    bool             `json:"souvenir"`
 	PaintIndex  json.Number      `json:"paint_index"`
+	Phase       string           `json:"phase"` // ex. Phase 2 or Ruby, only for the Doppler family
 	Collections []CollectionResp `json:"collections"`
 	Crates      []Crate          `json:"crates"`
 	Weapon      Weapon           `json:"weapon"`
@@ -186,8 +344,8 @@ type Skin struct {
 	Pattern     Pattern          `json:"pattern"`
 }
 
-func (c *CSGOAPIClient) FetchSkins() (SkinResponse, error) {
-	skins, err := getRequest[SkinResponse]("https://bymykel.github.io/CSGO-API/api/en/skins.json")
+func (c *CSGOAPIClient) FetchSkins(ctx context.Context) (SkinResponse, error) {
+	skins, err := getRequest[SkinResponse](ctx, c, SkinsEndpoint)
 	if err != nil {
 		return SkinResponse{}, err
 	}
@@ -209,10 +367,11 @@ type SkinItem struct {
 	Stattrak   bool        `json:"stattrak"`
 	Souvenir   bool        `json:"souvenir"`
 	PaintIndex json.Number `json:"paint_index"`
+	Phase      string      `json:"phase"`
 }
 
-func (c *CSGOAPIClient) FetchSkinItems() (SkinItemResponse, error) {
-	skins, err := getRequest[SkinItemResponse]("https://bymykel.github.io/CSGO-API/api/en/skins_not_grouped.json")
+func (c *CSGOAPIClient) FetchSkinItems(ctx context.Context) (SkinItemResponse, error) {
+	skins, err := getRequest[SkinItemResponse](ctx, c, SkinItemsEndpoint)
 	if err != nil {
 		return SkinItemResponse{}, err
 	}
@@ -233,8 +392,8 @@ type Collection struct {
 	Skins  []CollectionSkins `json:"contains"`
 }
 
-func (c *CSGOAPIClient) FetchCollections() (CollectionResponse, error) {
-	collections, err := getRequest[CollectionResponse]("https://bymykel.github.io/CSGO-API/api/en/collections.json")
+func (c *CSGOAPIClient) FetchCollections(ctx context.Context) (CollectionResponse, error) {
+	collections, err := getRequest[CollectionResponse](ctx, c, CollectionsEndpoint)
 	if err != nil {
 		return CollectionResponse{}, err
 	}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// noWait retries immediately so the tests don't sleep
+var noWait = RetryPolicy{MaxAttempts: 3}
+
+func skinsPayload(t *testing.T) []byte {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join(snapshot, "en", string(SkinsEndpoint)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+// dropBody answers with the headers and half of the payload, then closes the connection
+func dropBody(t *testing.T, w http.ResponseWriter, payload []byte) {
+	t.Helper()
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n")
+	buf.WriteString("Content-Length: " + strconv.Itoa(len(payload)) + "\r\n\r\n")
+	buf.Write(payload[:len(payload)/2])
+	buf.Flush()
+}
+
+func TestFetchRetriesDroppedBody(t *testing.T) {
+	payload := skinsPayload(t)
+
+	for name, cacheDir := range map[string]string{"without cache": "", "with cache": t.TempDir()} {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					dropBody(t, w, payload)
+					return
+				}
+				w.Write(payload)
+			}))
+			defer server.Close()
+
+			c := NewCSGOAPIClient(WithBaseURL(server.URL), WithCacheDir(cacheDir), WithRetryPolicy(noWait))
+			skins, err := c.FetchSkins(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(skins) != 1 || skins[0].Name != "AK-47 | Redline" {
+				t.Errorf("skins = %+v", skins)
+			}
+			if n := requests.Load(); n != 2 {
+				t.Errorf("made %d requests, want 2", n)
+			}
+		})
+	}
+}
+
+func TestFetchDoesNotRetryCacheErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(skinsPayload(t))
+	}))
+	defer server.Close()
+
+	// the cache directory can't be created below a regular file
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCSGOAPIClient(WithBaseURL(server.URL), WithCacheDir(filepath.Join(file, "cache")), WithRetryPolicy(noWait))
+	_, err := c.FetchSkins(context.Background())
+	if err == nil {
+		t.Fatal("cache error ignored")
+	}
+	if strings.Contains(err.Error(), "attempts") {
+		t.Errorf("cache error was retried: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests, want 1", n)
+	}
+}
+
+func TestFetchRetriesStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		requests int32
+		ok       bool
+	}{
+		{http.StatusServiceUnavailable, 2, true},
+		{http.StatusTooManyRequests, 2, true},
+		{http.StatusNotFound, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write(skinsPayload(t))
+			}))
+			defer server.Close()
+
+			c := NewCSGOAPIClient(WithBaseURL(server.URL), WithRetryPolicy(noWait))
+			_, err := c.FetchSkins(context.Background())
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok %v", err, tt.ok)
+			}
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("made %d requests, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestFetchRevalidatesCache(t *testing.T) {
+	payload := skinsPayload(t)
+	const etag = `"v1"`
+
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		c := NewCSGOAPIClient(WithBaseURL(server.URL), WithCacheDir(dir), WithRetryPolicy(noWait))
+		skins, err := c.FetchSkins(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(skins) != 1 {
+			t.Errorf("run %d read %d skins", i, len(skins))
+		}
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("made %d requests with %d not modified, want 2 with 1", requests.Load(), notModified.Load())
+	}
+}
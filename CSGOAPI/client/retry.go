@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried
+// network errors, 429 and 5xx responses are retried with exponential backoff and full jitter,
+// a Retry-After header of the response takes precedence over the backoff
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one, 1 disables retries
+	BaseDelay   time.Duration // backoff before the second attempt, doubled on each further attempt
+	MaxDelay    time.Duration // upper bound of a single wait, also caps Retry-After, 0 means no bound
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// DefaultTimeout bounds a single request including reading the body
+const DefaultTimeout = 2 * time.Minute
+
+// retryable reports whether a response with the status code should be retried
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// backoff returns the wait before the given retry, attempt starts at 1 for the first retry
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay + 1)
+}
+
+// delay returns the wait before the given retry, honoring the Retry-After header of res if present
+func (p RetryPolicy) delay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if after, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 {
+				return min(after, p.MaxDelay)
+			}
+			return after
+		}
+	}
+	return p.backoff(attempt)
+}
+
+// retryAfter parses a Retry-After value, either delay seconds or an http date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
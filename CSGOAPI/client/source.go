@@ -0,0 +1,284 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Endpoint is a file of the api, also used as the name of its snapshot
+type Endpoint string
+
+const (
+	StickersEndpoint    Endpoint = "stickers.json"
+	AgentsEndpoint      Endpoint = "agents.json"
+	PatchesEndpoint     Endpoint = "patches.json"
+	CharmsEndpoint      Endpoint = "keychains.json"
+	CasesEndpoint       Endpoint = "crates.json"
+	SkinsEndpoint       Endpoint = "skins.json"
+	SkinItemsEndpoint   Endpoint = "skins_not_grouped.json"
+	CollectionsEndpoint Endpoint = "collections.json"
+)
+
+// Source provides the data of the ByMykel/CSGO-API
+type Source interface {
+	FetchSkins(ctx context.Context) (SkinResponse, error)
+	FetchSkinItems(ctx context.Context) (SkinItemResponse, error)
+	FetchStickers(ctx context.Context) (StickerResponse, error)
+	FetchAgents(ctx context.Context) (AgentResponse, error)
+	FetchPatches(ctx context.Context) (PatchResponse, error)
+	FetchCharms(ctx context.Context) (CharmResponse, error)
+	FetchCases(ctx context.Context) (CaseResponse, error)
+	FetchCollections(ctx context.Context) (CollectionResponse, error)
+
+	// Digest returns the sha256 of the payload last read from the endpoint, empty if it wasn't read yet
+	Digest(e Endpoint) string
+
+	// Localized returns the same source in another language, ex. "de" or "zh-CN"
+	// the api publishes every language in a sibling directory of the english one (api/en, api/de, ...)
+	Localized(locale string) (Source, error)
+}
+
+var (
+	_ Source = (*CSGOAPIClient)(nil)
+	_ Source = (*fileSource)(nil)
+)
+
+// NewSource picks the source from spec:
+// an empty spec uses the public api, an http(s) url a mirror of it,
+// a .tar, .tar.gz or .tgz file an archive of snapshots and anything else a directory of snapshots
+// the options only apply to the http client
+func NewSource(spec string, opts ...ClientOption) (Source, error) {
+	switch {
+	case spec == "":
+		return NewCSGOAPIClient(opts...), nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return NewCSGOAPIClient(append(opts, WithBaseURL(spec))...), nil
+	case strings.HasSuffix(spec, ".tar"), strings.HasSuffix(spec, ".tar.gz"), strings.HasSuffix(spec, ".tgz"):
+		return NewArchiveSource(spec)
+	default:
+		return NewDirSource(spec)
+	}
+}
+
+// fileSource reads the api from snapshot files named like the endpoints, ex. skins.json
+type fileSource struct {
+	digests
+	open      func(name string) (io.ReadCloser, error)
+	localized func(locale string) (Source, error)
+}
+
+func (s *fileSource) Localized(locale string) (Source, error) {
+	return s.localized(locale)
+}
+
+// NewDirSource reads the snapshots from a directory
+func NewDirSource(dir string) (Source, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return &fileSource{
+		open: func(name string) (io.ReadCloser, error) {
+			return os.Open(filepath.Join(dir, name))
+		},
+		localized: func(locale string) (Source, error) {
+			return NewDirSource(filepath.Join(filepath.Dir(filepath.Clean(dir)), locale))
+		},
+	}, nil
+}
+
+// NewArchiveSource reads the snapshots from a tar archive, optionally gzip compressed
+// the english files are read from an en directory, else from the root of the archive or a single
+// top-level directory that isn't named after another language, ex. api/skins.json but not de/skins.json
+func NewArchiveSource(archive string) (Source, error) {
+	if _, err := os.Stat(archive); err != nil {
+		return nil, err
+	}
+
+	return &fileSource{
+		open: func(name string) (io.ReadCloser, error) {
+			if f, err := openInArchive(archive, func(p string) bool { return inLocaleDir(p, "en", name) }); err == nil {
+				return f, nil
+			}
+			return openInArchive(archive, func(p string) bool { return atRoot(p, name) })
+		},
+		localized: func(locale string) (Source, error) {
+			return newLocalizedArchiveSource(archive, locale), nil
+		},
+	}, nil
+}
+
+func newLocalizedArchiveSource(archive, locale string) Source {
+	return &fileSource{
+		open: func(name string) (io.ReadCloser, error) {
+			return openInArchive(archive, func(p string) bool { return inLocaleDir(p, locale, name) })
+		},
+		localized: func(locale string) (Source, error) {
+			return newLocalizedArchiveSource(archive, locale), nil
+		},
+	}
+}
+
+// inLocaleDir reports whether p is the file name inside a directory named after the locale
+func inLocaleDir(p, locale, name string) bool {
+	return path.Base(p) == name && path.Base(path.Dir(p)) == locale
+}
+
+// localePattern matches the directory names of the languages of the api, ex. de or zh-CN
+var localePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// atRoot reports whether p is the file name at the root of the archive or in a single top-level directory
+// that isn't named after a language
+func atRoot(p, name string) bool {
+	dir, base := path.Split(path.Clean(p))
+	dir = strings.TrimSuffix(dir, "/")
+	return base == name && (dir == "" || !strings.Contains(dir, "/") && !localePattern.MatchString(dir))
+}
+
+// openInArchive scans the archive for the first file matching, the archive is opened on every call
+// so the endpoints can be read concurrently
+func openInArchive(archive string, match func(p string) bool) (io.ReadCloser, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	var stream io.Reader = r
+	// gzip magic number
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		stream = gz
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && match(header.Name) {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, f}, nil
+		}
+	}
+
+	f.Close()
+	return nil, fmt.Errorf("no matching file in %s", archive)
+}
+
+// readFile decodes a snapshot to T
+func readFile[T any](ctx context.Context, s *fileSource, e Endpoint) (T, error) {
+	var zeroValue T
+	if err := ctx.Err(); err != nil {
+		return zeroValue, err
+	}
+
+	f, err := s.open(string(e))
+	if err != nil {
+		return zeroValue, fmt.Errorf("opening %s: %w", e, err)
+	}
+	defer f.Close()
+
+	return decode[T](&s.digests, e, f)
+}
+
+// digests keeps the sha256 of the payloads read from each endpoint
+type digests struct {
+	mu sync.Mutex
+	m  map[Endpoint]string
+}
+
+func (d *digests) Digest(e Endpoint) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.m[e]
+}
+
+func (d *digests) set(e Endpoint, digest string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.m == nil {
+		d.m = make(map[Endpoint]string)
+	}
+	d.m[e] = digest
+}
+
+// decode unmarshals the payload of the endpoint to T and records its digest
+func decode[T any](d *digests, e Endpoint, r io.Reader) (T, error) {
+	var response T
+
+	h := sha256.New()
+	tee := io.TeeReader(r, h)
+	if err := json.NewDecoder(tee).Decode(&response); err != nil {
+		var zeroValue T
+		return zeroValue, fmt.Errorf("decoding %s: %w", e, err)
+	}
+	// the decoder stops after the value, hash the rest of the payload too
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		var zeroValue T
+		return zeroValue, err
+	}
+
+	d.set(e, hex.EncodeToString(h.Sum(nil)))
+	return response, nil
+}
+
+func (s *fileSource) FetchSkins(ctx context.Context) (SkinResponse, error) {
+	return readFile[SkinResponse](ctx, s, SkinsEndpoint)
+}
+
+func (s *fileSource) FetchSkinItems(ctx context.Context) (SkinItemResponse, error) {
+	return readFile[SkinItemResponse](ctx, s, SkinItemsEndpoint)
+}
+
+func (s *fileSource) FetchStickers(ctx context.Context) (StickerResponse, error) {
+	return readFile[StickerResponse](ctx, s, StickersEndpoint)
+}
+
+func (s *fileSource) FetchAgents(ctx context.Context) (AgentResponse, error) {
+	return readFile[AgentResponse](ctx, s, AgentsEndpoint)
+}
+
+func (s *fileSource) FetchPatches(ctx context.Context) (PatchResponse, error) {
+	return readFile[PatchResponse](ctx, s, PatchesEndpoint)
+}
+
+func (s *fileSource) FetchCharms(ctx context.Context) (CharmResponse, error) {
+	return readFile[CharmResponse](ctx, s, CharmsEndpoint)
+}
+
+func (s *fileSource) FetchCases(ctx context.Context) (CaseResponse, error) {
+	return readFile[CaseResponse](ctx, s, CasesEndpoint)
+}
+
+func (s *fileSource) FetchCollections(ctx context.Context) (CollectionResponse, error) {
+	return readFile[CollectionResponse](ctx, s, CollectionsEndpoint)
+}
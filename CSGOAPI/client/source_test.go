@@ -0,0 +1,202 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// snapshot holds every endpoint in en and the skins in de
+const snapshot = "testdata/snapshot"
+
+// checkSource reads every endpoint of the snapshot from s and its german sibling
+func checkSource(t *testing.T, s Source) {
+	t.Helper()
+	ctx := context.Background()
+
+	skins, err := s.FetchSkins(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skins) != 1 || skins[0].Name != "AK-47 | Redline" || skins[0].Weapon.WeaponId != 7 || skins[0].PaintIndex != "282" {
+		t.Errorf("skins = %+v", skins)
+	}
+
+	counts := map[Endpoint]func() (int, error){
+		SkinItemsEndpoint:   func() (int, error) { r, err := s.FetchSkinItems(ctx); return len(r), err },
+		StickersEndpoint:    func() (int, error) { r, err := s.FetchStickers(ctx); return len(r), err },
+		AgentsEndpoint:      func() (int, error) { r, err := s.FetchAgents(ctx); return len(r), err },
+		PatchesEndpoint:     func() (int, error) { r, err := s.FetchPatches(ctx); return len(r), err },
+		CharmsEndpoint:      func() (int, error) { r, err := s.FetchCharms(ctx); return len(r), err },
+		CasesEndpoint:       func() (int, error) { r, err := s.FetchCases(ctx); return len(r), err },
+		CollectionsEndpoint: func() (int, error) { r, err := s.FetchCollections(ctx); return len(r), err },
+	}
+	for e, count := range counts {
+		n, err := count()
+		if err != nil {
+			t.Errorf("%s: %v", e, err)
+			continue
+		}
+		if n != 1 {
+			t.Errorf("%s: read %d entries, want 1", e, n)
+		}
+	}
+
+	payload, err := os.ReadFile(filepath.Join(snapshot, "en", string(SkinsEndpoint)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(payload)
+	if got, want := s.Digest(SkinsEndpoint), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("Digest(%s) = %s, want %s", SkinsEndpoint, got, want)
+	}
+
+	de, err := s.Localized("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	localized, err := de.FetchSkins(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(localized) != 1 || localized[0].Name != "AK-47 | Rote Linie" {
+		t.Errorf("de skins = %+v", localized)
+	}
+	if _, err := de.FetchStickers(ctx); err == nil {
+		t.Error("de has no stickers, the english ones must not be read instead")
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	s, err := NewSource(filepath.Join(snapshot, "en"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*fileSource); !ok {
+		t.Fatalf("NewSource returned %T, want a directory source", s)
+	}
+	checkSource(t, s)
+}
+
+func TestDirSourceMissing(t *testing.T) {
+	if _, err := NewDirSource(filepath.Join(snapshot, "fr")); err == nil {
+		t.Error("missing directory accepted")
+	}
+	if _, err := NewDirSource(filepath.Join(snapshot, "en", string(SkinsEndpoint))); err == nil {
+		t.Error("file accepted as a directory")
+	}
+}
+
+func TestArchiveSource(t *testing.T) {
+	for _, name := range []string{"api.tar", "api.tar.gz", "api.tgz"} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), name)
+			writeArchive(t, archive, path.Ext(name) != ".tar")
+
+			s, err := NewSource(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSource(t, s)
+		})
+	}
+}
+
+func TestArchiveSourceLayout(t *testing.T) {
+	skins, err := os.ReadFile(filepath.Join(snapshot, "en", string(SkinsEndpoint)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"skins.json", true},
+		{"./skins.json", true},
+		{"api/skins.json", true},
+		{"api/en/skins.json", true},
+		{"mirror/api/en/skins.json", true},
+		// another language is never served as english
+		{"de/skins.json", false},
+		{"zh-CN/skins.json", false},
+		{"api/de/skins.json", false},
+		{"mirror/api/skins.json", false},
+	}
+	for _, tt := range tests {
+		archive := filepath.Join(t.TempDir(), "api.tar")
+		writeTar(t, archive, false, map[string][]byte{tt.path: skins})
+
+		s, err := NewArchiveSource(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.FetchSkins(context.Background()); (err == nil) != tt.ok {
+			t.Errorf("%s read as english skins: %v, want %t", tt.path, err == nil, tt.ok)
+		}
+	}
+}
+
+// writeArchive packs the snapshot nested in an api directory, like a checkout of the api
+func writeArchive(t *testing.T, archive string, compress bool) {
+	t.Helper()
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(snapshot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(snapshot, p)
+		if err != nil {
+			return err
+		}
+		files[path.Join("api", filepath.ToSlash(rel))] = data
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTar(t, archive, compress, files)
+}
+
+// writeTar packs the files keyed by their path in the archive
+func writeTar(t *testing.T, archive string, compress bool, files map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	if compress {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
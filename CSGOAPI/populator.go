@@ -1,7 +1,12 @@
 package CSGOAPI
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,81 +17,226 @@ import (
 )
 
 type Populator struct {
-	c *client.CSGOAPIClient
+	c client.Source
 	r *repository.Repository
+
+	force   bool     // process endpoints even if their payload did not change
+	locales []string // languages whose names are stored as translations
+	report  map[string]repository.SyncResult
+}
+
+type Option func(*Populator)
+
+// WithBatchSize sets the number of rows written per INSERT statement
+func WithBatchSize(n int) Option {
+	return func(p *Populator) {
+		p.r.BatchSize = n
+	}
+}
+
+// WithSource replaces the public api with another source, ex. a directory of snapshots
+func WithSource(s client.Source) Option {
+	return func(p *Populator) {
+		p.c = s
+	}
+}
+
+// WithForce processes every endpoint, even those whose payload did not change since the last run
+func WithForce() Option {
+	return func(p *Populator) {
+		p.force = true
+	}
+}
+
+// WithLocales stores the names of the catalog in the given languages, ex. "de" or "zh-CN"
+// english names are always stored on the catalog rows themselves
+func WithLocales(locales ...string) Option {
+	return func(p *Populator) {
+		p.locales = locales
+	}
+}
+
+func NewPopulator(opts ...Option) *Populator {
+	p := &Populator{
+		c:      client.NewCSGOAPIClient(),
+		r:      repository.NewRepository(),
+		report: make(map[string]repository.SyncResult),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-func NewPopulator() *Populator {
-	return &Populator{}
+// tables written by processing each endpoint, a table is only checked for retirements
+// when every endpoint writing to it was processed in the run
+var endpointTables = map[client.Endpoint][]string{
+	client.StickersEndpoint:    {"cases", "rarities", "tournaments", "tournament_teams", "players", "stickers"},
+	client.SkinsEndpoint:       {"rarities", "collections", "weapons", "categories", "teams", "patterns", "cases", "wears", "skins", "skin_phases"},
+	client.SkinItemsEndpoint:   {"rarities", "weapons", "categories", "wears", "patterns", "item_skins"},
+	client.AgentsEndpoint:      {"collections", "teams", "rarities", "agents"},
+	client.PatchesEndpoint:     {"rarities", "patches"},
+	client.CharmsEndpoint:      {"collections", "rarities", "charms"},
+	client.CasesEndpoint:       {"rarities", "cases"},
+	client.CollectionsEndpoint: {"collections", "cases"},
 }
 
-func (p *Populator) PopulateDB() {
+// version of the processing of each endpoint, bump it when the step starts writing new columns or tables
+// so the first run after the upgrade processes the unchanged payload again and backfills them
+var endpointVersions = map[client.Endpoint]int{
+	client.StickersEndpoint:    1,
+	client.SkinsEndpoint:       1,
+	client.SkinItemsEndpoint:   3, // name of the item skins, upstream ids of the Doppler phases
+	client.AgentsEndpoint:      1,
+	client.PatchesEndpoint:     1,
+	client.CharmsEndpoint:      1,
+	client.CasesEndpoint:       1,
+	client.CollectionsEndpoint: 1,
+}
+
+// stepDigest is the digest recorded for a step, the payload digest tagged with the processing version
+// digests recorded before versions existed never match, which backfills every step once
+func stepDigest(e client.Endpoint, payload string) string {
+	if payload == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s@v%d", payload, endpointVersions[e])
+}
+
+func (p *Populator) PopulateDB(ctx context.Context) {
 	t := time.Now()
 
-	data, err := p.fetchData()
+	data, err := p.fetchData(ctx, p.c)
 	if err != nil {
 		panic(err)
 	}
 
-	if err := p.processStickers(data.Stickers); err != nil {
+	previous, err := p.r.LastPayloads(database.DB)
+	if err != nil {
 		panic(err)
 	}
 
-	if err := p.processSkins(data.Skins); err != nil {
+	run, err := p.r.BeginRun(database.DB)
+	if err != nil {
 		panic(err)
 	}
 
-	if err := p.processSkinItems(data.SkinItems); err != nil {
-		panic(err)
+	steps := []struct {
+		endpoint client.Endpoint
+		process  func() error
+	}{
+		{client.StickersEndpoint, func() error { return p.processStickers(data.Stickers) }},
+		{client.SkinsEndpoint, func() error { return p.processSkins(data.Skins) }},
+		{client.SkinItemsEndpoint, func() error { return p.processSkinItems(data.SkinItems) }},
+		{client.AgentsEndpoint, func() error { return p.processAgents(data.Agents) }},
+		{client.PatchesEndpoint, func() error { return p.processPatches(data.Patches) }},
+		{client.CharmsEndpoint, func() error { return p.processCharms(data.Charms) }},
+		{client.CollectionsEndpoint, func() error { return p.processCollections(data.Collections) }},
+		// drops resolve to the items stored by the steps above
+		{client.CasesEndpoint, func() error { return p.processCases(data.Cases) }},
+	}
+
+	digests := make(map[string]string)
+	var skipped []string
+	for _, step := range steps {
+		digest := stepDigest(step.endpoint, p.c.Digest(step.endpoint))
+		digests[string(step.endpoint)] = digest
+
+		if !p.force && digest != "" && digest == previous[string(step.endpoint)] {
+			fmt.Printf("Skipping %s, unchanged since the last run\n", step.endpoint)
+			skipped = append(skipped, endpointTables[step.endpoint]...)
+			continue
+		}
+
+		if err := step.process(); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, locale := range p.locales {
+		if err := p.processLocale(ctx, locale); err != nil {
+			panic(err)
+		}
 	}
 
-	if err = p.processAgents(data.Agents); err != nil {
+	if err := p.r.RecordPayloads(run.ID, digests, database.DB); err != nil {
 		panic(err)
 	}
 
-	if err = p.processPatches(data.Patches); err != nil {
+	// everything still in the database but missing from this fetch is retired
+	retirements, err := p.r.FinishRun(&run, skipped, database.DB)
+	if err != nil {
 		panic(err)
 	}
 
-	if err = p.processCharms(data.Charms); err != nil {
+	// the registry is generated from the whole catalog, so it also runs when every endpoint was skipped
+	if err := p.processItems(); err != nil {
 		panic(err)
 	}
 
+	for _, entity := range slices.Sorted(maps.Keys(p.report)) {
+		fmt.Printf("Synced %s: %s\n", entity, p.report[entity])
+	}
+	fmt.Printf("Retired %d rows in run %d\n", len(retirements), run.ID)
 	fmt.Println("Time since start: ", time.Since(t))
 }
 
+// record adds the result of a sync to the per entity report of the run
+func (p *Populator) record(entity string, result repository.SyncResult) {
+	p.report[entity] = p.report[entity].Add(result)
+}
+
+// Report returns the inserted/updated/unchanged counts of every synced entity
+func (p *Populator) Report() map[string]repository.SyncResult {
+	return maps.Clone(p.report)
+}
+
 func (p *Populator) processStickers(s client.StickerResponse) error {
+	var crates []client.Crate
+	var rarities []client.Rarity
+	var events, teams []string
+	for _, sticker := range s {
+		crates = append(crates, sticker.Crate...)
+		rarities = append(rarities, sticker.Rarity)
+		events = append(events, sticker.TournamentEvent)
+		teams = append(teams, sticker.TournamentTeam)
+	}
+
 	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, sticker := range s {
-			crates, err := p.r.CreateCrate(sticker.Crate, tx)
-			if err != nil {
-				return err
-			}
+		_, crateResult, err := p.r.UpsertCrates(crates, tx)
+		if err != nil {
+			return err
+		}
+		p.record("cases", crateResult)
 
-			rarity, err := p.r.CreateRarity(&sticker.Rarity, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
 
-			tournament, err := p.r.CreateTournament(&sticker.TournamentEvent, tx)
-			if err != nil {
-				return err
-			}
+		tournaments, err := p.r.UpsertTournaments(events, tx)
+		if err != nil {
+			return err
+		}
 
-			team, err := p.r.CreateTournamentTeam(&sticker.TournamentTeam, tx)
-			if err != nil {
-				return err
-			}
+		tournamentTeams, err := p.r.UpsertTournamentTeams(teams, tx)
+		if err != nil {
+			return err
+		}
 
-			if _, err := p.r.CreateTournamentTeamRelation(&tournament, tx); err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertTournamentTeamRelations(s, tournaments, tournamentTeams, tx); err != nil {
+			return err
+		}
 
-			if _, err := p.r.CreateSticker(&sticker, &tournament, &team, &rarity, crates, tx); err != nil {
-				return err
-			}
+		players, err := p.r.UpsertPlayers(s, tournamentTeams, tx)
+		if err != nil {
+			return err
+		}
 
+		_, stickerResult, err := p.r.UpsertStickers(s, tournaments, tournamentTeams, players, tx)
+		if err != nil {
+			return err
 		}
+		p.record("stickers", stickerResult)
 		return nil
 	}); err != nil {
 		return err
@@ -96,72 +246,94 @@ func (p *Populator) processStickers(s client.StickerResponse) error {
 }
 
 func (p *Populator) processSkins(s client.SkinResponse) error {
+	var rarities []client.Rarity
+	var collections []client.CollectionResp
+	var categories []client.Category
+	var teams []client.Team
+	var patterns []client.Pattern
+	var crates []client.Crate
+	var wears []client.Wear
+	for _, skin := range s {
+		rarities = append(rarities, skin.Rarity)
+		collections = append(collections, skin.Collections...)
+		categories = append(categories, skin.Category)
+		teams = append(teams, skin.Team)
+		patterns = append(patterns, skin.Pattern)
+		crates = append(crates, skin.Crates...)
+		wears = append(wears, skin.Wears...)
+	}
+
 	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, skin := range s {
-
-			rarity, err := p.r.CreateRarity(&skin.Rarity, tx)
-			if err != nil {
-				return err
-			}
-
-			collections, err := p.r.CreateCollection(skin.Collections, tx)
-			if err != nil {
-				return err
-			}
-
-			weapon, err := p.r.CreateWeapon(&skin.Weapon, tx)
-			if err != nil {
-				return err
-			}
-
-			category, err := p.r.CreateCategory(&skin.Category, tx)
-			if err != nil {
-				return err
-			}
-
-			team, err := p.r.CreateTeam(&skin.Team, tx)
-			if err != nil {
-				return err
-			}
-
-			pattern, err := p.r.CreatePattern(&skin.Pattern, tx)
-			if err != nil {
-				return err
-			}
-
-			crates, err := p.r.CreateCrate(skin.Crates, tx)
-			if err != nil {
-				return err
-			}
-
-			wears, err := p.r.CreateWears(skin.Wears, tx)
-			if err != nil {
-				//continue if no wears were created as some skins dont have wears such as vanillas
-				if err.Error() != "no valid wears were created" {
-					return err
-				}
-			}
-
-			var collectionID *string
-			if len(collections) > 0 {
-				collectionID = &collections[0].ID
-			}
-
-			_, err = p.r.CreateSkin(&skin, &rarity.ID, &weapon.ID, collectionID, &category.ID, &team.ID, &pattern.ID, wears, tx)
-			if err != nil {
-				return err
-			}
-
-			_, err = p.r.CreateSkinCrateAssociation(&skin.ID, crates, tx)
-			if err != nil {
-				return err
-			}
-
-			_, err = p.r.CreateSkinWearAssociation(&skin.ID, wears, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
+
+		_, collectionResult, err := p.r.UpsertCollections(collections, tx)
+		if err != nil {
+			return err
+		}
+		p.record("collections", collectionResult)
+
+		if _, err := p.r.UpsertSkinWeapons(s, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertCategories(categories, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertTeams(teams, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertPatterns(patterns, tx); err != nil {
+			return err
+		}
+
+		_, crateResult, err := p.r.UpsertCrates(crates, tx)
+		if err != nil {
+			return err
+		}
+		p.record("cases", crateResult)
+
+		if _, err := p.r.UpsertWears(wears, tx); err != nil {
+			return err
+		}
+
+		_, skinResult, err := p.r.UpsertSkins(s, tx)
+		if err != nil {
+			return err
+		}
+		p.record("skins", skinResult)
 
+		_, phaseResult, err := p.r.UpsertSkinPhases(s, tx)
+		if err != nil {
+			return err
+		}
+		p.record("skin_phases", phaseResult)
+
+		if _, err := p.r.UpsertSkinCrateAssociations(s, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertSkinWearAssociations(s, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertSkinCollectionAssociations(s, tx); err != nil {
+			return err
+		}
+
+		// the wears of a skin follow from its float range, report skins where upstream disagrees
+		mismatches, err := p.r.CheckSkinWears(tx)
+		if err != nil {
+			return err
+		}
+		for _, mismatch := range mismatches {
+			log.Printf("Wear mismatch: %s", mismatch)
+		}
+		if len(mismatches) > 0 {
+			fmt.Printf("Found %d skins whose wears don't match their float range\n", len(mismatches))
 		}
 		return nil
 	}); err != nil {
@@ -171,42 +343,45 @@ func (p *Populator) processSkins(s client.SkinResponse) error {
 }
 
 func (p *Populator) processSkinItems(s client.SkinItemResponse) error {
+	var rarities []client.Rarity
+	var weapons []client.Weapon
+	var categories []client.Category
+	var wears []client.Wear
+	var patterns []client.Pattern
+	for _, skin := range s {
+		rarities = append(rarities, skin.Rarity)
+		weapons = append(weapons, skin.Weapon)
+		categories = append(categories, skin.Category)
+		wears = append(wears, skin.Wear)
+		patterns = append(patterns, skin.Pattern)
+	}
+
 	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, skin := range s {
-
-			_, err := p.r.CreateRarity(&skin.Rarity, tx)
-			if err != nil {
-				return err
-			}
-
-			_, err = p.r.CreateWeapon(&skin.Weapon, tx)
-			if err != nil {
-				return err
-			}
-
-			_, err = p.r.CreateCategory(&skin.Category, tx)
-			if err != nil {
-				return err
-			}
-
-			wears, err := p.r.CreateWears([]client.Wear{skin.Wear}, tx)
-			if err != nil {
-				//continue if no wears were created as some skins dont have wears such as vanillas
-				if err.Error() != "no valid wears were created" {
-					return err
-				}
-			}
-
-			_, err = p.r.CreatePattern(&skin.Pattern, tx)
-			if err != nil {
-				return err
-			}
-
-			_, err = p.r.CreateSkinItem(&skin, wears, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
 		}
+
+		if _, err := p.r.UpsertWeapons(weapons, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertCategories(categories, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertWears(wears, tx); err != nil {
+			return err
+		}
+
+		if _, err := p.r.UpsertPatterns(patterns, tx); err != nil {
+			return err
+		}
+
+		_, skinItemResult, err := p.r.UpsertSkinItems(s, tx)
+		if err != nil {
+			return err
+		}
+		p.record("skin items", skinItemResult)
 		return nil
 	}); err != nil {
 		return err
@@ -215,28 +390,38 @@ func (p *Populator) processSkinItems(s client.SkinItemResponse) error {
 }
 
 func (p *Populator) processAgents(a client.AgentResponse) error {
+	var collections []client.CollectionResp
+	var teams []client.Team
+	var rarities []client.Rarity
+	for _, agent := range a {
+		collections = append(collections, agent.Collections...)
+		teams = append(teams, agent.Team)
+		rarities = append(rarities, agent.Rarity)
+	}
+
 	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, agent := range a {
+		_, collectionResult, err := p.r.UpsertCollections(collections, tx)
+		if err != nil {
+			return err
+		}
+		p.record("collections", collectionResult)
 
-			_, err := p.r.CreateCollection(agent.Collections, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertTeams(teams, tx); err != nil {
+			return err
+		}
 
-			_, err = p.r.CreateTeam(&agent.Team, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
 
-			_, err = p.r.CreateRarity(&agent.Rarity, tx)
-			if err != nil {
-				return err
-			}
+		_, agentResult, err := p.r.UpsertAgents(a, tx)
+		if err != nil {
+			return err
+		}
+		p.record("agents", agentResult)
 
-			_, err = p.r.CreateAgent(&agent, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.UpsertAgentCollectionAssociations(a, tx); err != nil {
+			return err
 		}
 		return nil
 	}); err != nil {
@@ -246,19 +431,21 @@ func (p *Populator) processAgents(a client.AgentResponse) error {
 }
 
 func (p *Populator) processPatches(pa client.PatchResponse) error {
-	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, patch := range pa {
+	var rarities []client.Rarity
+	for _, patch := range pa {
+		rarities = append(rarities, patch.Rarity)
+	}
 
-			_, err := p.r.CreateRarity(&patch.Rarity, tx)
-			if err != nil {
-				return err
-			}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
 
-			_, err = p.r.CreatePatch(&patch, tx)
-			if err != nil {
-				return err
-			}
+		_, patchResult, err := p.r.UpsertPatches(pa, tx)
+		if err != nil {
+			return err
 		}
+		p.record("patches", patchResult)
 		return nil
 	}); err != nil {
 		return err
@@ -267,24 +454,105 @@ func (p *Populator) processPatches(pa client.PatchResponse) error {
 }
 
 func (p *Populator) processCharms(c client.CharmResponse) error {
+	var collections []client.CollectionResp
+	var rarities []client.Rarity
+	for _, charm := range c {
+		collections = append(collections, charm.Collections...)
+		rarities = append(rarities, charm.Rarity)
+	}
+
 	if err := database.DB.Transaction(func(tx *gorm.DB) error {
-		for _, charm := range c {
+		_, collectionResult, err := p.r.UpsertCollections(collections, tx)
+		if err != nil {
+			return err
+		}
+		p.record("collections", collectionResult)
+
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
+
+		_, charmResult, err := p.r.UpsertCharms(c, tx)
+		if err != nil {
+			return err
+		}
+		p.record("charms", charmResult)
+
+		if _, err := p.r.UpsertCharmCollectionAssociations(c, tx); err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Populator) processCases(c client.CaseResponse) error {
+	var rarities []client.Rarity
+	for _, crate := range c {
+		for _, item := range crate.Contains {
+			rarities = append(rarities, item.Rarity)
+		}
+		for _, item := range crate.ContainsRare {
+			rarities = append(rarities, item.Rarity)
+		}
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := p.r.UpsertRarities(rarities, tx); err != nil {
+			return err
+		}
+
+		_, caseResult, err := p.r.UpsertCases(c, tx)
+		if err != nil {
+			return err
+		}
+		p.record("cases", caseResult)
 
-			_, err := p.r.CreateCollection(charm.Collections, tx)
-			if err != nil {
-				return err
-			}
+		if _, err := p.r.ReplaceCaseDrops(c, tx); err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
 
-			_, err = p.r.CreateRarity(&charm.Rarity, tx)
-			if err != nil {
-				return err
-			}
+func (p *Populator) processCollections(c client.CollectionResponse) error {
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		_, collectionResult, err := p.r.UpsertCollectionContents(c, tx)
+		if err != nil {
+			return err
+		}
+		p.record("collections", collectionResult)
+
+		// both endpoints are upstream views of the same data, report where they disagree
+		mismatches, err := p.r.ReconcileCollections(tx)
+		if err != nil {
+			return err
+		}
+		for _, mismatch := range mismatches {
+			log.Printf("Collection mismatch: %s", mismatch)
+		}
+		if len(mismatches) > 0 {
+			fmt.Printf("Found %d mismatches between the collections and skins endpoints\n", len(mismatches))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
 
-			_, err = p.r.CreateCharm(&charm, tx)
-			if err != nil {
-				return err
-			}
+func (p *Populator) processItems() error {
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		itemResult, err := p.r.SyncItems(tx)
+		if err != nil {
+			return err
 		}
+		p.record("items", itemResult)
 		return nil
 	}); err != nil {
 		return err
@@ -299,10 +567,12 @@ type FetchedData struct {
 	Skins       client.SkinResponse
 	Stickers    client.StickerResponse
 	SkinItems   client.SkinItemResponse
+	Cases       client.CaseResponse
+	Collections client.CollectionResponse
 	Errors      map[string]error
 }
 
-func (p *Populator) fetchData() (*FetchedData, error) {
+func (p *Populator) fetchData(ctx context.Context, c client.Source) (*FetchedData, error) {
 
 	wg := sync.WaitGroup{}
 	mu := sync.Mutex{}
@@ -314,7 +584,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		agents, err := p.c.FetchAgents()
+		agents, err := c.FetchAgents(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["agents"] = err
@@ -329,7 +599,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		patches, err := p.c.FetchPatches()
+		patches, err := c.FetchPatches(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["patches"] = err
@@ -344,7 +614,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		charms, err := p.c.FetchCharms()
+		charms, err := c.FetchCharms(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["charms"] = err
@@ -359,7 +629,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		skins, err := p.c.FetchSkins()
+		skins, err := c.FetchSkins(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["skins"] = err
@@ -374,7 +644,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		stickers, err := p.c.FetchStickers()
+		stickers, err := c.FetchStickers(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["stickers"] = err
@@ -389,7 +659,7 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		skinItems, err := p.c.FetchSkinItems()
+		skinItems, err := c.FetchSkinItems(ctx)
 		mu.Lock()
 		if err != nil {
 			result.Errors["skinItems"] = err
@@ -400,12 +670,46 @@ func (p *Populator) fetchData() (*FetchedData, error) {
 		mu.Unlock()
 	}()
 
+	// Fetch cases
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cases, err := c.FetchCases(ctx)
+		mu.Lock()
+		if err != nil {
+			result.Errors["cases"] = err
+		} else {
+			result.Cases = cases
+			fmt.Printf("Fetched %d cases\n", len(cases))
+		}
+		mu.Unlock()
+	}()
+
+	// Fetch collections
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collections, err := c.FetchCollections(ctx)
+		mu.Lock()
+		if err != nil {
+			result.Errors["collections"] = err
+		} else {
+			result.Collections = collections
+			fmt.Printf("Fetched %d collections\n", len(collections))
+		}
+		mu.Unlock()
+	}()
+
 	// Wait for all goroutines to finish
 	wg.Wait()
 
 	// Check if there were any errors
 	if len(result.Errors) > 0 {
-		return result, fmt.Errorf("some fetch operations failed")
+		var errs []error
+		for _, name := range slices.Sorted(maps.Keys(result.Errors)) {
+			errs = append(errs, fmt.Errorf("%s: %w", name, result.Errors[name]))
+		}
+		return result, fmt.Errorf("some fetch operations failed: %w", errors.Join(errs...))
 	}
 
 	return result, nil
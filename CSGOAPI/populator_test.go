@@ -0,0 +1,199 @@
+package CSGOAPI
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/repository"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"gorm.io/gorm"
+)
+
+func TestEndpointVersions(t *testing.T) {
+	for e := range endpointTables {
+		if endpointVersions[e] < 1 {
+			t.Errorf("%s has no processing version", e)
+		}
+	}
+}
+
+func TestStepDigest(t *testing.T) {
+	const payload = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	if got := stepDigest(client.SkinsEndpoint, ""); got != "" {
+		t.Errorf("unread endpoint got digest %q", got)
+	}
+
+	// a digest recorded before the versions existed must not skip the step
+	if stepDigest(client.SkinsEndpoint, payload) == payload {
+		t.Error("step digest matches the bare payload digest")
+	}
+
+	before := stepDigest(client.SkinsEndpoint, payload)
+	endpointVersions[client.SkinsEndpoint]++
+	defer func() { endpointVersions[client.SkinsEndpoint]-- }()
+	if stepDigest(client.SkinsEndpoint, payload) == before {
+		t.Error("bumping the version kept the step digest")
+	}
+}
+
+// fixtureCopies is the number of times the snapshot entries are repeated with new ids by the benchmarks
+const fixtureCopies = 2000
+
+// snapshotFixture reads the checked-in snapshot and repeats its skins, skin items and stickers n times,
+// a quarter of the stickers are team stickers of a tournament
+func snapshotFixture(tb testing.TB, n int) *FetchedData {
+	tb.Helper()
+	s, err := client.NewDirSource("client/testdata/snapshot/en")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	data, err := NewPopulator().fetchData(context.Background(), s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var skins client.SkinResponse
+	var skinItems client.SkinItemResponse
+	var stickers client.StickerResponse
+	for i := range n {
+		for _, skin := range data.Skins {
+			skin.ID = fmt.Sprintf("%s-%d", skin.ID, i)
+			skin.Name = fmt.Sprintf("%s %d", skin.Name, i)
+			skin.PaintIndex = json.Number(strconv.Itoa(10000 + i))
+			skins = append(skins, skin)
+		}
+		for _, item := range data.SkinItems {
+			item.ID = fmt.Sprintf("%s-%d", item.ID, i)
+			item.SkinId = fmt.Sprintf("%s-%d", item.SkinId, i)
+			item.Name = fmt.Sprintf("%s %d", item.Name, i)
+			item.MarketHashName = fmt.Sprintf("%s %d", item.MarketHashName, i)
+			item.PaintIndex = json.Number(strconv.Itoa(10000 + i))
+			skinItems = append(skinItems, item)
+		}
+		for _, sticker := range data.Stickers {
+			sticker.ID = fmt.Sprintf("%s-%d", sticker.ID, i)
+			sticker.MarketHashName = fmt.Sprintf("%s %d", sticker.MarketHashName, i)
+			sticker.Name = sticker.MarketHashName
+			if i%4 == 0 {
+				sticker.TournamentEvent = fmt.Sprintf("Tournament %d", i%40)
+				sticker.TournamentTeam = fmt.Sprintf("Team %d", i%160)
+			}
+			stickers = append(stickers, sticker)
+		}
+	}
+	data.Skins, data.SkinItems, data.Stickers = skins, skinItems, stickers
+	return data
+}
+
+// testDB migrates the database of DATABASE_URL, the test is skipped without one
+func testDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	if os.Getenv("DATABASE_URL") == "" {
+		tb.Skip("DATABASE_URL not set")
+	}
+	if database.DB == nil {
+		database.InitDB()
+	}
+	return database.DB
+}
+
+// inTransaction points database.DB at a transaction for the duration of f and rolls it back,
+// the transactions of the process steps become savepoints of it
+func inTransaction(tb testing.TB, db *gorm.DB, f func() error) {
+	tb.Helper()
+	tx := db.Begin()
+	database.DB = tx
+	defer func() {
+		database.DB = db
+		tx.Rollback()
+	}()
+	if err := f(); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestProcessTwiceInsertsNothing(t *testing.T) {
+	db := testDB(t)
+	data := snapshotFixture(t, 20)
+	p := NewPopulator()
+
+	process := func() error {
+		if err := p.processStickers(data.Stickers); err != nil {
+			return err
+		}
+		if err := p.processSkins(data.Skins); err != nil {
+			return err
+		}
+		return p.processSkinItems(data.SkinItems)
+	}
+
+	inTransaction(t, db, func() error {
+		if err := process(); err != nil {
+			return err
+		}
+		for _, entity := range []string{"stickers", "skins", "skin items"} {
+			if p.report[entity].Inserted != 20 {
+				t.Errorf("first run %s: %s, want 20 inserted", entity, p.report[entity])
+			}
+		}
+
+		p.report = make(map[string]repository.SyncResult)
+		if err := process(); err != nil {
+			return err
+		}
+		for entity, result := range p.report {
+			if result.Inserted != 0 || result.Updated != 0 {
+				t.Errorf("second run %s: %s, want every row unchanged", entity, result)
+			}
+		}
+		return nil
+	})
+}
+
+// BenchmarkProcess runs the process steps on the fixture with one row per statement, the row by row path,
+// and with the default batches
+func BenchmarkProcess(b *testing.B) {
+	db := testDB(b)
+	data := snapshotFixture(b, fixtureCopies)
+
+	steps := []struct {
+		name    string
+		prepare func(p *Populator) error // writes what the step depends on, not timed
+		process func(p *Populator) error
+	}{
+		{"Stickers", nil, func(p *Populator) error { return p.processStickers(data.Stickers) }},
+		{"Skins", nil, func(p *Populator) error { return p.processSkins(data.Skins) }},
+		{
+			"SkinItems",
+			func(p *Populator) error { return p.processSkins(data.Skins) },
+			func(p *Populator) error { return p.processSkinItems(data.SkinItems) },
+		},
+	}
+
+	for _, step := range steps {
+		for _, batch := range []int{1, repository.DefaultBatchSize} {
+			b.Run(fmt.Sprintf("%s/batch=%d", step.name, batch), func(b *testing.B) {
+				for range b.N {
+					p := NewPopulator(WithBatchSize(batch))
+					b.StopTimer()
+					inTransaction(b, db, func() error {
+						if step.prepare != nil {
+							if err := step.prepare(p); err != nil {
+								return err
+							}
+						}
+						b.StartTimer()
+						defer b.StopTimer()
+						return step.process(p)
+					})
+				}
+			})
+		}
+	}
+}
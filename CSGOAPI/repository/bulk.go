@@ -0,0 +1,547 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is the number of rows sent per statement by the Upsert* methods
+const DefaultBatchSize = 1000
+
+// insertBatches writes rows with INSERT ... ON CONFLICT DO NOTHING in batches of size n
+// rows that already exist are left untouched, use syncRows for entities that need updates
+func insertBatches[T any](rows []T, n int, tx *gorm.DB) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Omit(clause.Associations).
+		CreateInBatches(rows, n).Error
+}
+
+// uniqueBy drops every row whose key was already seen, keeping the first occurrence
+// postgres can't touch the same row twice in one INSERT ... ON CONFLICT statement
+func uniqueBy[T any](rows []T, key func(T) string) []T {
+	seen := make(map[string]struct{}, len(rows))
+	unique := make([]T, 0, len(rows))
+	for _, row := range rows {
+		k := key(row)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		unique = append(unique, row)
+	}
+	return unique
+}
+
+func (r *Repository) batchSize() int {
+	if r.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return r.BatchSize
+}
+
+func (r *Repository) UpsertCrates(c []client.Crate, tx *gorm.DB) ([]models.Case, SyncResult, error) {
+	crates := make([]models.Case, 0, len(c))
+	for _, crate := range c {
+		crates = append(crates, crateModel(&crate))
+	}
+	crates = uniqueBy(crates, func(c models.Case) string { return c.ID })
+
+	result, err := syncRows(crates, func(c models.Case) string { return c.ID }, crateColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, crates, func(c models.Case) string { return c.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return crates, result, nil
+}
+
+func (r *Repository) UpsertRarities(rar []client.Rarity, tx *gorm.DB) ([]models.Rarity, error) {
+	rarities := make([]models.Rarity, 0, len(rar))
+	for _, rarity := range rar {
+		rarities = append(rarities, rarityModel(&rarity))
+	}
+	rarities = uniqueBy(rarities, func(r models.Rarity) string { return r.ID })
+
+	if err := insertBatches(rarities, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, rarities, func(r models.Rarity) string { return r.ID }, tx); err != nil {
+		return nil, err
+	}
+	return rarities, nil
+}
+
+func (r *Repository) UpsertCollections(c []client.CollectionResp, tx *gorm.DB) ([]models.Collection, SyncResult, error) {
+	collections := make([]models.Collection, 0, len(c))
+	for _, collection := range c {
+		collections = append(collections, collectionModel(&collection))
+	}
+	collections = uniqueBy(collections, func(c models.Collection) string { return c.ID })
+
+	result, err := syncRows(collections, func(c models.Collection) string { return c.ID }, collectionColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, collections, func(c models.Collection) string { return c.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return collections, result, nil
+}
+
+func (r *Repository) UpsertWears(w []client.Wear, tx *gorm.DB) ([]models.Wear, error) {
+	wears := make([]models.Wear, 0, len(w))
+	for _, wear := range w {
+		model, ok := wearModel(&wear)
+		if !ok {
+			// some skins dont have wears such as vanillas
+			continue
+		}
+		wears = append(wears, model)
+	}
+	wears = uniqueBy(wears, func(w models.Wear) string { return w.ID })
+
+	if err := insertBatches(wears, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, wears, func(w models.Wear) string { return w.ID }, tx); err != nil {
+		return nil, err
+	}
+	return wears, nil
+}
+
+func (r *Repository) UpsertPatterns(p []client.Pattern, tx *gorm.DB) ([]models.Pattern, error) {
+	patterns := make([]models.Pattern, 0, len(p))
+	for _, pattern := range p {
+		patterns = append(patterns, patternModel(&pattern))
+	}
+	patterns = uniqueBy(patterns, func(p models.Pattern) string { return p.ID })
+
+	if err := insertBatches(patterns, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, patterns, func(p models.Pattern) string { return p.ID }, tx); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func (r *Repository) UpsertTeams(t []client.Team, tx *gorm.DB) ([]models.Team, error) {
+	teams := make([]models.Team, 0, len(t))
+	for _, team := range t {
+		teams = append(teams, teamModel(&team))
+	}
+	teams = uniqueBy(teams, func(t models.Team) string { return t.ID })
+
+	if err := insertBatches(teams, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, teams, func(t models.Team) string { return t.ID }, tx); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (r *Repository) UpsertCategories(c []client.Category, tx *gorm.DB) ([]models.Category, error) {
+	categories := make([]models.Category, 0, len(c))
+	for _, category := range c {
+		categories = append(categories, categoryModel(&category))
+	}
+	categories = uniqueBy(categories, func(c models.Category) string { return c.ID })
+
+	if err := insertBatches(categories, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, categories, func(c models.Category) string { return c.ID }, tx); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *Repository) UpsertWeapons(w []client.Weapon, tx *gorm.DB) ([]models.Weapon, error) {
+	weapons := make([]models.Weapon, 0, len(w))
+	for _, weapon := range w {
+		weapons = append(weapons, weaponModel(&weapon))
+	}
+	weapons = uniqueBy(weapons, func(w models.Weapon) string { return w.ID })
+
+	// weapons stored before the def index was kept need to be updated
+	if _, err := syncRows(weapons, func(w models.Weapon) string { return w.ID }, weaponColumns, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, weapons, func(w models.Weapon) string { return w.ID }, tx); err != nil {
+		return nil, err
+	}
+	return weapons, nil
+}
+
+// UpsertSkinWeapons syncs the weapons of the skins with their class, sides and ★ flag
+func (r *Repository) UpsertSkinWeapons(s []client.Skin, tx *gorm.DB) ([]models.Weapon, error) {
+	weapons := make([]models.Weapon, 0, len(s))
+	for _, skin := range s {
+		weapons = append(weapons, skinWeaponModel(&skin))
+	}
+	weapons = uniqueBy(weapons, func(w models.Weapon) string { return w.ID })
+
+	if _, err := syncRows(weapons, func(w models.Weapon) string { return w.ID }, skinWeaponColumns, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	if err := markSeen(r, weapons, func(w models.Weapon) string { return w.ID }, tx); err != nil {
+		return nil, err
+	}
+	return weapons, nil
+}
+
+// UpsertTournaments creates the missing tournaments and returns all of them keyed by name
+// empty names, ex. of stickers outside of tournaments, are skipped
+func (r *Repository) UpsertTournaments(names []string, tx *gorm.DB) (map[string]models.Tournament, error) {
+	names = uniqueBy(names, func(n string) string { return n })
+	names = slices.DeleteFunc(names, func(n string) bool { return n == "" })
+
+	tournaments := make([]models.Tournament, 0, len(names))
+	for _, name := range names {
+		tournaments = append(tournaments, models.Tournament{Name: name})
+	}
+
+	if err := insertBatches(tournaments, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+
+	// ids of rows skipped by ON CONFLICT are not returned, read them back
+	var stored []models.Tournament
+	if err := tx.Where("name IN ?", names).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	if err := markSeen(r, stored, func(t models.Tournament) uint32 { return t.ID }, tx); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]models.Tournament, len(stored))
+	for _, t := range stored {
+		byName[t.Name] = t
+	}
+	return byName, nil
+}
+
+// UpsertTournamentTeams creates the missing tournament teams and returns all of them keyed by name
+// empty names are skipped
+func (r *Repository) UpsertTournamentTeams(names []string, tx *gorm.DB) (map[string]models.TournamentTeam, error) {
+	names = uniqueBy(names, func(n string) string { return n })
+	names = slices.DeleteFunc(names, func(n string) bool { return n == "" })
+
+	teams := make([]models.TournamentTeam, 0, len(names))
+	for _, name := range names {
+		teams = append(teams, models.TournamentTeam{Team: name})
+	}
+
+	if err := insertBatches(teams, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+
+	// ids of rows skipped by ON CONFLICT are not returned, read them back
+	var stored []models.TournamentTeam
+	if err := tx.Where("team IN ?", names).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	if err := markSeen(r, stored, func(t models.TournamentTeam) uint32 { return t.ID }, tx); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]models.TournamentTeam, len(stored))
+	for _, t := range stored {
+		byName[t.Team] = t
+	}
+	return byName, nil
+}
+
+// UpsertTournamentTeamRelations stores which teams had stickers at which tournament
+// the relations of the tournaments are replaced, so teams whose stickers disappeared upstream are dropped
+func (r *Repository) UpsertTournamentTeamRelations(s []client.Sticker, t map[string]models.Tournament, tot map[string]models.TournamentTeam, tx *gorm.DB) ([]models.TournamentTeamRelation, error) {
+	var rel []models.TournamentTeamRelation
+	for _, sticker := range s {
+		tournament, ok := t[sticker.TournamentEvent]
+		if !ok {
+			continue
+		}
+		team, ok := tot[sticker.TournamentTeam]
+		if !ok {
+			continue
+		}
+		rel = append(rel, models.TournamentTeamRelation{
+			TournamentID:     tournament.ID,
+			TournamentTeamID: team.ID,
+		})
+	}
+	rel = uniqueBy(rel, func(r models.TournamentTeamRelation) string {
+		return fmt.Sprintf("%d-%d", r.TournamentID, r.TournamentTeamID)
+	})
+
+	tournamentIDs := make([]uint32, 0, len(t))
+	for _, tournament := range t {
+		tournamentIDs = append(tournamentIDs, tournament.ID)
+	}
+	if err := replaceAssociations(rel, "tournament_id", tournamentIDs, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return rel, nil
+}
+
+// UpsertStickers expects the tournaments, teams and players of the stickers to be created already
+func (r *Repository) UpsertStickers(s []client.Sticker, t map[string]models.Tournament, tot map[string]models.TournamentTeam, p map[string]models.Player, tx *gorm.DB) ([]models.Sticker, SyncResult, error) {
+	stickers := make([]models.Sticker, 0, len(s))
+	for _, sticker := range s {
+		var tournament *models.Tournament
+		if stored, ok := t[sticker.TournamentEvent]; ok {
+			tournament = &stored
+		}
+		var team *models.TournamentTeam
+		if stored, ok := tot[sticker.TournamentTeam]; ok {
+			team = &stored
+		}
+		var player *models.Player
+		if stored, ok := p[classifySticker(&sticker).Player]; ok {
+			player = &stored
+		}
+		stickers = append(stickers, stickerModel(&sticker, tournament, team, player))
+	}
+	stickers = uniqueBy(stickers, func(s models.Sticker) string { return s.ID })
+
+	result, err := syncRows(stickers, func(s models.Sticker) string { return s.ID }, stickerColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, stickers, func(s models.Sticker) string { return s.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return stickers, result, nil
+}
+
+func (r *Repository) UpsertSkins(s []client.Skin, tx *gorm.DB) ([]models.Skin, SyncResult, error) {
+	skins := make([]models.Skin, 0, len(s))
+	for _, skin := range s {
+		skins = append(skins, skinModel(&skin))
+	}
+	skins = uniqueBy(skins, func(s models.Skin) string { return s.ID })
+
+	result, err := syncRows(skins, func(s models.Skin) string { return s.ID }, skinColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, skins, func(s models.Skin) string { return s.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return skins, result, nil
+}
+
+// UpsertSkinPhases stores the phases of the Doppler family skins, the skins are expected to be created already
+func (r *Repository) UpsertSkinPhases(s []client.Skin, tx *gorm.DB) ([]models.SkinPhase, SyncResult, error) {
+	var skinPhases []models.SkinPhase
+	for _, skin := range s {
+		if phase, ok := skinPhaseModel(&skin); ok {
+			skinPhases = append(skinPhases, phase)
+		}
+	}
+	skinPhases = uniqueBy(skinPhases, func(p models.SkinPhase) string { return p.ID })
+
+	result, err := syncRows(skinPhases, func(p models.SkinPhase) string { return p.ID }, skinPhaseColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, skinPhases, func(p models.SkinPhase) string { return p.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return skinPhases, result, nil
+}
+
+func (r *Repository) UpsertSkinCrateAssociations(s []client.Skin, tx *gorm.DB) ([]models.SkinCrate, error) {
+	var skinCrates []models.SkinCrate
+	for _, skin := range s {
+		for _, crate := range skin.Crates {
+			skinCrates = append(skinCrates, models.SkinCrate{
+				SkinID: skin.ID,
+				CaseID: crate.ID,
+			})
+		}
+	}
+	skinCrates = uniqueBy(skinCrates, func(s models.SkinCrate) string { return s.SkinID + "-" + s.CaseID })
+
+	if err := insertBatches(skinCrates, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return skinCrates, nil
+}
+
+func (r *Repository) UpsertSkinWearAssociations(s []client.Skin, tx *gorm.DB) ([]models.SkinWear, error) {
+	var skinWears []models.SkinWear
+	for _, skin := range s {
+		for _, wear := range skin.Wears {
+			if _, ok := wearModel(&wear); !ok {
+				continue
+			}
+			skinWears = append(skinWears, models.SkinWear{
+				SkinID: skin.ID,
+				WearID: wear.ID,
+			})
+		}
+	}
+	skinWears = uniqueBy(skinWears, func(s models.SkinWear) string { return s.SkinID + "-" + s.WearID })
+
+	if err := insertBatches(skinWears, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return skinWears, nil
+}
+
+func (r *Repository) UpsertSkinItems(s []client.SkinItem, tx *gorm.DB) ([]models.ItemSkin, SyncResult, error) {
+	skinItems := make([]models.ItemSkin, 0, len(s))
+	for _, skin := range s {
+		skinItems = append(skinItems, skinItemModel(&skin))
+	}
+	skinItems = uniqueBy(skinItems, func(s models.ItemSkin) string { return s.ID })
+
+	result, err := syncRows(skinItems, func(s models.ItemSkin) string { return s.ID }, skinItemColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, skinItems, func(s models.ItemSkin) string { return s.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return skinItems, result, nil
+}
+
+func (r *Repository) UpsertAgents(a []client.Agent, tx *gorm.DB) ([]models.Agent, SyncResult, error) {
+	agents := make([]models.Agent, 0, len(a))
+	for _, agent := range a {
+		agents = append(agents, agentModel(&agent))
+	}
+	agents = uniqueBy(agents, func(a models.Agent) string { return a.ID })
+
+	result, err := syncRows(agents, func(a models.Agent) string { return a.ID }, agentColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, agents, func(a models.Agent) string { return a.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return agents, result, nil
+}
+
+func (r *Repository) UpsertPatches(p []client.Patch, tx *gorm.DB) ([]models.Patch, SyncResult, error) {
+	patches := make([]models.Patch, 0, len(p))
+	for _, patch := range p {
+		patches = append(patches, patchModel(&patch))
+	}
+	patches = uniqueBy(patches, func(p models.Patch) string { return p.ID })
+
+	result, err := syncRows(patches, func(p models.Patch) string { return p.ID }, patchColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, patches, func(p models.Patch) string { return p.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return patches, result, nil
+}
+
+func (r *Repository) UpsertCharms(c []client.Charm, tx *gorm.DB) ([]models.Charm, SyncResult, error) {
+	charms := make([]models.Charm, 0, len(c))
+	for _, charm := range c {
+		charms = append(charms, charmModel(&charm))
+	}
+	charms = uniqueBy(charms, func(c models.Charm) string { return c.ID })
+
+	result, err := syncRows(charms, func(c models.Charm) string { return c.ID }, charmColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, charms, func(c models.Charm) string { return c.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return charms, result, nil
+}
+
+// replaceAssociations replaces the join table rows of the owners, so memberships removed upstream disappear
+// column is the join table column referencing the owner
+func replaceAssociations[T any, K comparable](rows []T, column string, owners []K, n int, tx *gorm.DB) error {
+	owners = uniqueBy(owners, func(o K) string { return fmt.Sprint(o) })
+	for start := 0; start < len(owners); start += n {
+		end := min(start+n, len(owners))
+		if err := tx.Where(column+" IN ?", owners[start:end]).Delete(new(T)).Error; err != nil {
+			return err
+		}
+	}
+	return insertBatches(rows, n, tx)
+}
+
+// UpsertSkinCollectionAssociations stores every collection of the skins, the collections are expected to be created already
+func (r *Repository) UpsertSkinCollectionAssociations(s []client.Skin, tx *gorm.DB) ([]models.SkinCollection, error) {
+	var skinCollections []models.SkinCollection
+	skinIDs := make([]string, 0, len(s))
+	for _, skin := range s {
+		skinIDs = append(skinIDs, skin.ID)
+		for _, collection := range skin.Collections {
+			skinCollections = append(skinCollections, models.SkinCollection{
+				SkinID:       skin.ID,
+				CollectionID: collection.ID,
+			})
+		}
+	}
+	skinCollections = uniqueBy(skinCollections, func(s models.SkinCollection) string { return s.SkinID + "-" + s.CollectionID })
+
+	if err := replaceAssociations(skinCollections, "skin_id", skinIDs, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return skinCollections, nil
+}
+
+// UpsertAgentCollectionAssociations stores every collection of the agents, the collections are expected to be created already
+func (r *Repository) UpsertAgentCollectionAssociations(a []client.Agent, tx *gorm.DB) ([]models.AgentCollection, error) {
+	var agentCollections []models.AgentCollection
+	agentIDs := make([]string, 0, len(a))
+	for _, agent := range a {
+		agentIDs = append(agentIDs, agent.ID)
+		for _, collection := range agent.Collections {
+			agentCollections = append(agentCollections, models.AgentCollection{
+				AgentID:      agent.ID,
+				CollectionID: collection.ID,
+			})
+		}
+	}
+	agentCollections = uniqueBy(agentCollections, func(a models.AgentCollection) string { return a.AgentID + "-" + a.CollectionID })
+
+	if err := replaceAssociations(agentCollections, "agent_id", agentIDs, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return agentCollections, nil
+}
+
+// UpsertCharmCollectionAssociations stores every collection of the charms, the collections are expected to be created already
+func (r *Repository) UpsertCharmCollectionAssociations(c []client.Charm, tx *gorm.DB) ([]models.CharmCollection, error) {
+	var charmCollections []models.CharmCollection
+	charmIDs := make([]string, 0, len(c))
+	for _, charm := range c {
+		charmIDs = append(charmIDs, charm.ID)
+		for _, collection := range charm.Collections {
+			charmCollections = append(charmCollections, models.CharmCollection{
+				CharmID:      charm.ID,
+				CollectionID: collection.ID,
+			})
+		}
+	}
+	charmCollections = uniqueBy(charmCollections, func(c models.CharmCollection) string { return c.CharmID + "-" + c.CollectionID })
+
+	if err := replaceAssociations(charmCollections, "charm_id", charmIDs, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return charmCollections, nil
+}
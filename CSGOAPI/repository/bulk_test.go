@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// fixtureSize is the number of patches written per iteration, the fixture is repeated with new ids to reach it
+const fixtureSize = 5000
+
+func patchFixture(tb testing.TB, n int) []client.Patch {
+	tb.Helper()
+	data, err := os.ReadFile("testdata/patches.json")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var fixture []client.Patch
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		tb.Fatal(err)
+	}
+
+	patches := make([]client.Patch, 0, n)
+	for i := 0; len(patches) < n; i++ {
+		p := fixture[i%len(fixture)]
+		p.ID = fmt.Sprintf("bench-patch-%d", i)
+		p.Name = fmt.Sprintf("%s %d", p.Name, i) // names are unique
+		patches = append(patches, p)
+	}
+	return patches
+}
+
+// testDB migrates the database of DATABASE_URL, the test is skipped without one
+func testDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	if os.Getenv("DATABASE_URL") == "" {
+		tb.Skip("DATABASE_URL not set")
+	}
+	if database.DB == nil {
+		database.InitDB()
+	}
+	return database.DB
+}
+
+// firstOrCreatePatches is the path the populator used before the Upsert* methods, one query per record
+func firstOrCreatePatches(p []client.Patch, tx *gorm.DB) error {
+	for _, patch := range p {
+		var rarity models.Rarity
+		if err := tx.FirstOrCreate(&rarity, models.Rarity{
+			ID:    patch.Rarity.ID,
+			Name:  patch.Rarity.Name.(string),
+			Color: patch.Rarity.Color,
+		}).Error; err != nil {
+			return err
+		}
+
+		var model models.Patch
+		if err := tx.FirstOrCreate(&model, patchModel(&patch)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// a second sync of the same payload must find every row and write none
+func TestUpsertTwiceInsertsNothing(t *testing.T) {
+	db := testDB(t)
+	patches := patchFixture(t, 100)
+	r := NewRepository()
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	rarities := make([]client.Rarity, 0, len(patches))
+	for _, p := range patches {
+		rarities = append(rarities, p.Rarity)
+	}
+	var results [2]SyncResult
+	for i := range results {
+		if _, err := r.UpsertRarities(rarities, tx); err != nil {
+			t.Fatal(err)
+		}
+		_, result, err := r.UpsertPatches(patches, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results[i] = result
+	}
+
+	if results[0] != (SyncResult{Inserted: len(patches)}) {
+		t.Errorf("first sync: %s, want %d inserted", results[0], len(patches))
+	}
+	if results[1] != (SyncResult{Unchanged: len(patches)}) {
+		t.Errorf("second sync: %s, want %d unchanged", results[1], len(patches))
+	}
+
+	var stored int64
+	if err := tx.Model(&models.Patch{}).Where("id LIKE ?", "bench-patch-%").Count(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored != int64(len(patches)) {
+		t.Errorf("stored %d patches, want %d", stored, len(patches))
+	}
+}
+
+func TestUpsertTournamentTeamsTwice(t *testing.T) {
+	db := testDB(t)
+	r := NewRepository()
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	names := []string{"test-team Vitality", "test-team FaZe Clan", "test-team Vitality", ""}
+	first, err := r.UpsertTournamentTeams(names, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.UpsertTournamentTeams(names[1:], tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("got %d and %d teams, want 2", len(first), len(second))
+	}
+	for name, team := range first {
+		if team.ID == 0 || second[name].ID != team.ID {
+			t.Errorf("%s stored as %d, then as %d", name, team.ID, second[name].ID)
+		}
+	}
+}
+
+func BenchmarkPatches(b *testing.B) {
+	db := testDB(b)
+	patches := patchFixture(b, fixtureSize)
+	r := NewRepository()
+
+	// every iteration writes into an empty table and rolls back
+	run := func(b *testing.B, write func(tx *gorm.DB) error) {
+		for i := 0; i < b.N; i++ {
+			tx := db.Begin()
+			if err := write(tx); err != nil {
+				tx.Rollback()
+				b.Fatal(err)
+			}
+			tx.Rollback()
+		}
+	}
+
+	b.Run("FirstOrCreate", func(b *testing.B) {
+		run(b, func(tx *gorm.DB) error { return firstOrCreatePatches(patches, tx) })
+	})
+	b.Run("Upsert", func(b *testing.B) {
+		run(b, func(tx *gorm.DB) error {
+			rarities := make([]client.Rarity, 0, len(patches))
+			for _, p := range patches {
+				rarities = append(rarities, p.Rarity)
+			}
+			if _, err := r.UpsertRarities(rarities, tx); err != nil {
+				return err
+			}
+			_, _, err := r.UpsertPatches(patches, tx)
+			return err
+		})
+	})
+}
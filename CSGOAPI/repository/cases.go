@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// cases synced from the crates endpoint also know their type, crates of skins and stickers don't
+var caseColumns = append([]string{"type"}, crateColumns...)
+
+// UpsertCases syncs the cases of the crates endpoint, their drops are written by ReplaceCaseDrops
+func (r *Repository) UpsertCases(c []client.Cases, tx *gorm.DB) ([]models.Case, SyncResult, error) {
+	cases := make([]models.Case, 0, len(c))
+	for _, crate := range c {
+		cases = append(cases, caseModel(&crate))
+	}
+	cases = uniqueBy(cases, func(c models.Case) string { return c.ID })
+
+	result, err := syncRows(cases, func(c models.Case) string { return c.ID }, caseColumns, r.batchSize(), tx)
+	if err != nil {
+		return nil, result, err
+	}
+	if err := markSeen(r, cases, func(c models.Case) string { return c.ID }, tx); err != nil {
+		return nil, result, err
+	}
+	return cases, result, nil
+}
+
+// ReplaceCaseDrops replaces the drops of the cases with their current contents
+// items are linked to the skin, sticker, agent or charm they resolve to when it is stored
+func (r *Repository) ReplaceCaseDrops(c []client.Cases, tx *gorm.DB) ([]models.CaseDrop, error) {
+	var drops []models.CaseDrop
+	caseIDs := make([]string, 0, len(c))
+	for _, crate := range c {
+		caseIDs = append(caseIDs, crate.ID)
+		for _, item := range crate.Contains {
+			drops = append(drops, caseDropModel(crate.ID, &item, false))
+		}
+		for _, item := range crate.ContainsRare {
+			drops = append(drops, caseDropModel(crate.ID, &item, true))
+		}
+	}
+	drops = uniqueBy(drops, func(d models.CaseDrop) string { return d.CaseID + "-" + d.ItemID })
+
+	if err := r.resolveDrops(drops, tx); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(caseIDs); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(caseIDs))
+		if err := tx.Where("case_id IN ?", caseIDs[start:end]).Delete(&models.CaseDrop{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := insertBatches(drops, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return drops, nil
+}
+
+// resolveDrops links the drops to the catalog rows of their items
+// the id prefix of the api tells the kind, the row must exist to satisfy the foreign key
+func (r *Repository) resolveDrops(drops []models.CaseDrop, tx *gorm.DB) error {
+	kinds := []struct {
+		prefix string
+		model  any
+		set    func(d *models.CaseDrop, id *string)
+	}{
+		{"skin-", &models.Skin{}, func(d *models.CaseDrop, id *string) { d.SkinId = id }},
+		{"sticker-", &models.Sticker{}, func(d *models.CaseDrop, id *string) { d.StickerId = id }},
+		{"agent-", &models.Agent{}, func(d *models.CaseDrop, id *string) { d.AgentId = id }},
+		{"keychain-", &models.Charm{}, func(d *models.CaseDrop, id *string) { d.CharmId = id }},
+	}
+
+	for _, kind := range kinds {
+		var ids []string
+		for _, drop := range drops {
+			if strings.HasPrefix(drop.ItemID, kind.prefix) {
+				ids = append(ids, drop.ItemID)
+			}
+		}
+
+		stored, err := r.existingIDs(kind.model, ids, tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range drops {
+			if _, ok := stored[drops[i].ItemID]; ok {
+				id := drops[i].ItemID
+				kind.set(&drops[i], &id)
+			}
+		}
+	}
+	return nil
+}
+
+// existingIDs returns which of the ids are stored in the table of model
+func (r *Repository) existingIDs(model any, ids []string, tx *gorm.DB) (map[string]struct{}, error) {
+	existing := make(map[string]struct{}, len(ids))
+	for start := 0; start < len(ids); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(ids))
+
+		var found []string
+		if err := tx.Model(model).Where("id IN ?", ids[start:end]).Pluck("id", &found).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			existing[id] = struct{}{}
+		}
+	}
+	return existing, nil
+}
+
+// CaseDropTable returns the case with its drops and the catalog rows they resolve to
+// regular drops come first, both groups are grouped by rarity
+func (r *Repository) CaseDropTable(caseID string, tx *gorm.DB) (models.Case, error) {
+	var c models.Case
+	if err := tx.
+		Preload("Drops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("rare, rarity_id, name")
+		}).
+		Preload("Drops.Rarity").
+		Preload("Drops.Skin").
+		Preload("Drops.Sticker").
+		Preload("Drops.Agent").
+		Preload("Drops.Charm").
+		First(&c, "id = ?", caseID).Error; err != nil {
+		return models.Case{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// CollectionMismatch is a skin on which the collections and the skins endpoint disagree
+type CollectionMismatch struct {
+	SkinID   string // empty if the listed skin could not be resolved
+	Name     string
+	Listed   string // collection listing the skin in the collections endpoint, empty if it doesn't
+	Assigned string // collection of the skin in the skins endpoint, its primary one if the skin isn't a member of Listed
+}
+
+func (m CollectionMismatch) String() string {
+	switch {
+	case m.SkinID == "":
+		return fmt.Sprintf("%s listed in %s is not a known skin", m.Name, m.Listed)
+	case m.Listed == "":
+		return fmt.Sprintf("%s (%s) belongs to %s but is not listed by it", m.Name, m.SkinID, m.Assigned)
+	case m.Assigned == "":
+		return fmt.Sprintf("%s (%s) is listed in %s but has no collection", m.Name, m.SkinID, m.Listed)
+	default:
+		return fmt.Sprintf("%s (%s) is listed in %s but belongs to %s", m.Name, m.SkinID, m.Listed, m.Assigned)
+	}
+}
+
+// UpsertCollectionContents syncs the collections of the collections endpoint with their crates and skins
+// the skins are expected to be stored already, see ReconcileCollections for entries that don't match
+func (r *Repository) UpsertCollectionContents(c []client.Collection, tx *gorm.DB) ([]models.Collection, SyncResult, error) {
+	var refs []client.CollectionResp
+	var crates []client.Crate
+	for _, collection := range c {
+		refs = append(refs, client.CollectionResp(collection.NameIDImage))
+		crates = append(crates, collection.Crates...)
+	}
+
+	collections, result, err := r.UpsertCollections(refs, tx)
+	if err != nil {
+		return nil, result, err
+	}
+
+	if _, _, err := r.UpsertCrates(crates, tx); err != nil {
+		return nil, result, err
+	}
+	for _, collection := range c {
+		var crateIDs []string
+		for _, crate := range collection.Crates {
+			crateIDs = append(crateIDs, crate.ID)
+		}
+		if len(crateIDs) == 0 {
+			continue
+		}
+		if err := tx.Model(&models.Case{}).Where("id IN ?", crateIDs).Update("collection_id", collection.ID).Error; err != nil {
+			return nil, result, err
+		}
+	}
+
+	var contents []models.CollectionSkin
+	collectionIDs := make([]string, 0, len(c))
+	for _, collection := range c {
+		collectionIDs = append(collectionIDs, collection.ID)
+		for _, skin := range collection.Skins {
+			contents = append(contents, collectionSkinModel(collection.ID, &skin))
+		}
+	}
+	contents = uniqueBy(contents, func(c models.CollectionSkin) string { return c.CollectionID + "-" + c.ItemID })
+
+	if err := r.resolveCollectionSkins(contents, tx); err != nil {
+		return nil, result, err
+	}
+
+	// the contents are replaced as a whole so skins removed from a collection disappear
+	for start := 0; start < len(collectionIDs); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(collectionIDs))
+		if err := tx.Where("collection_id IN ?", collectionIDs[start:end]).Delete(&models.CollectionSkin{}).Error; err != nil {
+			return nil, result, err
+		}
+	}
+	if err := insertBatches(contents, r.batchSize(), tx); err != nil {
+		return nil, result, err
+	}
+	return collections, result, nil
+}
+
+// resolveCollectionSkins links the entries to stored skins, by id or else by paint index and name
+func (r *Repository) resolveCollectionSkins(contents []models.CollectionSkin, tx *gorm.DB) error {
+	ids := make([]string, 0, len(contents))
+	for _, entry := range contents {
+		ids = append(ids, entry.ItemID)
+	}
+	stored, err := r.existingIDs(&models.Skin{}, ids, tx)
+	if err != nil {
+		return err
+	}
+
+	var paintIndexes []uint16
+	for i := range contents {
+		if _, ok := stored[contents[i].ItemID]; ok {
+			id := contents[i].ItemID
+			contents[i].SkinId = &id
+		} else {
+			paintIndexes = append(paintIndexes, contents[i].PaintIndex)
+		}
+	}
+	if len(paintIndexes) == 0 {
+		return nil
+	}
+
+	// the same paint can be applied to several weapons, so the name has to match too
+	var candidates []models.Skin
+	if err := tx.Select("id", "name", "paint_index").Where("paint_index IN ?", uniqueBy(paintIndexes, func(i uint16) string { return fmt.Sprint(i) })).Find(&candidates).Error; err != nil {
+		return err
+	}
+	byPaint := make(map[string]string, len(candidates))
+	for _, skin := range candidates {
+		byPaint[fmt.Sprintf("%d-%s", skin.PaintIndex, skin.Name)] = skin.ID
+	}
+
+	// collections list every phase of the Doppler family, they share a skin
+	var phases []struct {
+		SkinID     string
+		Name       string
+		PaintIndex uint16
+	}
+	if err := tx.Table("skin_phases AS p").
+		Select("p.skin_id, s.name, p.paint_index").
+		Joins("JOIN skins s ON s.id = p.skin_id").
+		Where("p.paint_index IN ?", uniqueBy(paintIndexes, func(i uint16) string { return fmt.Sprint(i) })).
+		Scan(&phases).Error; err != nil {
+		return err
+	}
+	for _, phase := range phases {
+		byPaint[fmt.Sprintf("%d-%s", phase.PaintIndex, phase.Name)] = phase.SkinID
+	}
+
+	for i := range contents {
+		if contents[i].SkinId != nil {
+			continue
+		}
+		if id, ok := byPaint[fmt.Sprintf("%d-%s", contents[i].PaintIndex, contents[i].Name)]; ok {
+			contents[i].SkinId = &id
+		}
+	}
+	return nil
+}
+
+// ReconcileCollections compares the contents of the collections endpoint with the collections of each skin
+// only collections with stored contents are compared, skins of other collections can't be checked
+func (r *Repository) ReconcileCollections(tx *gorm.DB) ([]CollectionMismatch, error) {
+	var mismatches []CollectionMismatch
+
+	var unresolved []models.CollectionSkin
+	if err := tx.Where("skin_id IS NULL").Order("collection_id, name").Find(&unresolved).Error; err != nil {
+		return nil, err
+	}
+	for _, entry := range unresolved {
+		mismatches = append(mismatches, CollectionMismatch{Name: entry.Name, Listed: entry.CollectionID})
+	}
+
+	// listed in a collection the skin is not a member of
+	var listed []struct {
+		SkinID   string
+		Name     string
+		Listed   string
+		Assigned *string
+	}
+	if err := tx.Table("collection_skins AS cs").
+		Select("s.id AS skin_id, s.name, cs.collection_id AS listed, s.collection_id AS assigned").
+		Joins("JOIN skins s ON s.id = cs.skin_id").
+		Where("NOT EXISTS (SELECT 1 FROM skin_collections sc WHERE sc.skin_id = s.id AND sc.collection_id = cs.collection_id)").
+		Order("cs.collection_id, s.name").
+		Scan(&listed).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range listed {
+		mismatch := CollectionMismatch{SkinID: m.SkinID, Name: m.Name, Listed: m.Listed}
+		if m.Assigned != nil {
+			mismatch.Assigned = *m.Assigned
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	// member of a collection with contents that doesn't list it
+	var assigned []struct {
+		SkinID   string
+		Name     string
+		Assigned string
+	}
+	if err := tx.Table("skin_collections AS sc").
+		Select("s.id AS skin_id, s.name, sc.collection_id AS assigned").
+		Joins("JOIN skins s ON s.id = sc.skin_id").
+		Where("sc.collection_id IN (SELECT DISTINCT collection_id FROM collection_skins)").
+		Where("NOT EXISTS (SELECT 1 FROM collection_skins cs WHERE cs.skin_id = sc.skin_id AND cs.collection_id = sc.collection_id)").
+		Order("sc.collection_id, s.name").
+		Scan(&assigned).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range assigned {
+		mismatches = append(mismatches, CollectionMismatch{SkinID: m.SkinID, Name: m.Name, Assigned: m.Assigned})
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+// converts the api responses to their database models
+
+func crateModel(c *client.Crate) models.Case {
+	return models.Case{
+		ID:    c.ID,
+		Name:  c.Name.(string),
+		Image: c.Image,
+	}
+}
+
+func rarityModel(rar *client.Rarity) models.Rarity {
+	return models.Rarity{
+		ID:    rar.ID,
+		Name:  rar.Name.(string),
+		Color: rar.Color,
+	}
+}
+
+func collectionModel(c *client.CollectionResp) models.Collection {
+	return models.Collection{
+		ID:    c.ID,
+		Name:  c.Name.(string),
+		Image: c.Image,
+	}
+}
+
+// wearModel returns false if the wear has no valid name
+func wearModel(w *client.Wear) (models.Wear, bool) {
+	name, ok := w.Name.(string)
+	if !ok {
+		return models.Wear{}, false
+	}
+	return models.Wear{
+		ID:   w.ID,
+		Name: models.WearType(name),
+	}, true
+}
+
+func patternModel(p *client.Pattern) models.Pattern {
+	return models.Pattern{
+		ID:   p.ID,
+		Name: safeGetString(p.Name, p.ID, "Name"),
+	}
+}
+
+func teamModel(t *client.Team) models.Team {
+	return models.Team{
+		ID:   t.ID,
+		Name: t.Name.(string),
+	}
+}
+
+func categoryModel(c *client.Category) models.Category {
+	return models.Category{
+		ID:   c.ID,
+		Name: c.Name.(string),
+	}
+}
+
+func weaponModel(w *client.Weapon) models.Weapon {
+	return models.Weapon{
+		ID:       w.ID,
+		Name:     w.Name.(string),
+		DefIndex: w.WeaponId,
+	}
+}
+
+// def indexes of the sniper rifles, the api files them under rifles
+var sniperDefIndexes = map[uint16]struct{}{
+	9:  {}, // AWP
+	11: {}, // G3SG1
+	38: {}, // SCAR-20
+	40: {}, // SSG 08
+}
+
+// weapon classes by the english name of the skin category
+var weaponClasses = map[string]models.WeaponClass{
+	"Pistols": models.PistolClass,
+	"SMGs":    models.SMGClass,
+	"Rifles":  models.RifleClass,
+	"Heavy":   models.HeavyClass,
+	"Knives":  models.KnifeClass,
+	"Gloves":  models.GlovesClass,
+}
+
+// skinWeaponModel is the weapon of a skin with the metadata only the skins endpoint knows
+func skinWeaponModel(s *client.Skin) models.Weapon {
+	weapon := weaponModel(&s.Weapon)
+
+	category, _ := s.Category.Name.(string)
+	weapon.Class = weaponClasses[category]
+	if _, ok := sniperDefIndexes[weapon.DefIndex]; ok && weapon.Class == models.RifleClass {
+		weapon.Class = models.SniperClass
+	}
+
+	switch s.Team.ID {
+	case "terrorists":
+		weapon.Terrorist = true
+	case "counter-terrorists":
+		weapon.CounterTerrorist = true
+	default:
+		weapon.Terrorist, weapon.CounterTerrorist = true, true
+	}
+
+	name, _ := s.Name.(string)
+	weapon.Special = weapon.Class == models.KnifeClass || weapon.Class == models.GlovesClass || strings.HasPrefix(name, "★")
+	return weapon
+}
+
+// stickerModel leaves the tournament, team and player unset when t, tot or p is nil
+func stickerModel(s *client.Sticker, t *models.Tournament, tot *models.TournamentTeam, p *models.Player) models.Sticker {
+	class := classifySticker(s)
+	sticker := models.Sticker{
+		ID:             s.ID,
+		Name:           s.Name.(string),
+		MarketHashName: s.MarketHashName,
+		Image:          s.Image,
+		RarityId:       s.Rarity.ID,
+		Kind:           class.Kind,
+		Effect:         class.Effect,
+	}
+	if len(s.Crate) > 0 {
+		sticker.CaseID = &s.Crate[0].ID
+	}
+	if t != nil {
+		sticker.TournamentId = &t.ID
+	}
+	if tot != nil {
+		sticker.TeamId = &tot.ID
+	}
+	if p != nil {
+		sticker.PlayerId = &p.ID
+	}
+	return sticker
+}
+
+// primaryCollection returns the first collection listed, nil if there is none
+// every collection is kept in the join tables, see UpsertSkinCollectionAssociations
+func primaryCollection(c []client.CollectionResp) *string {
+	if len(c) == 0 {
+		return nil
+	}
+	return &c[0].ID
+}
+
+func paintIndex(n json.Number) uint16 {
+	if value, err := n.Int64(); err == nil {
+		return uint16(value)
+	}
+	return 0 // Default value in case of error
+}
+
+// phases of the Doppler family by paint index, for snapshots without a phase field
+var dopplerPhases = map[uint16]models.DopplerPhase{
+	// Doppler
+	415: models.Ruby,
+	416: models.Sapphire,
+	417: models.BlackPearl,
+	418: models.Phase1,
+	419: models.Phase2,
+	420: models.Phase3,
+	421: models.Phase4,
+	617: models.BlackPearl,
+	618: models.Phase2,
+	619: models.Sapphire,
+	852: models.Phase1,
+	853: models.Phase2,
+	854: models.Phase3,
+	855: models.Phase4,
+	// Gamma Doppler
+	568:  models.Emerald,
+	569:  models.Phase1,
+	570:  models.Phase2,
+	571:  models.Phase3,
+	572:  models.Phase4,
+	1119: models.Emerald,
+	1120: models.Phase1,
+	1121: models.Phase2,
+	1122: models.Phase3,
+	1123: models.Phase4,
+}
+
+// dopplerPhase returns the phase of the api, or the one of the paint index when the api has none
+func dopplerPhase(phase string, paint json.Number) (models.DopplerPhase, bool) {
+	for _, p := range models.DopplerPhases {
+		if strings.EqualFold(strings.TrimSpace(phase), string(p)) {
+			return p, true
+		}
+	}
+	p, ok := dopplerPhases[paintIndex(paint)]
+	return p, ok
+}
+
+// phaseID is the id of a phase of the skin, ex. skin-...-phase-2
+func phaseID(id string, p models.DopplerPhase) string {
+	return id + "-" + strings.ToLower(strings.ReplaceAll(string(p), " ", "-"))
+}
+
+func skinPhaseModel(s *client.Skin) (models.SkinPhase, bool) {
+	phase, ok := dopplerPhase(s.Phase, s.PaintIndex)
+	if !ok {
+		return models.SkinPhase{}, false
+	}
+	return models.SkinPhase{
+		ID:         phaseID(s.ID, phase),
+		SkinId:     s.ID,
+		Phase:      phase,
+		PaintIndex: paintIndex(s.PaintIndex),
+		Image:      s.Image,
+	}, true
+}
+
+func skinModel(s *client.Skin) models.Skin {
+	skin := models.Skin{
+		ID:         s.ID,
+		Name:       s.Name.(string),
+		Image:      s.Image,
+		RarityId:   s.Rarity.ID,
+		WeaponId:   s.Weapon.ID,
+		PaintIndex: paintIndex(s.PaintIndex),
+		MinFloat:   s.MinFloat,
+		MaxFloat:   s.MaxFloat,
+		Stattrak:   s.Stattrak,
+		Souvenir:   s.Souvenir,
+		CategoryId: s.Category.ID,
+		TeamId:     s.Team.ID,
+		PatternId:  s.Pattern.ID,
+	}
+	skin.CollectionId = primaryCollection(s.Collections)
+	return skin
+}
+
+func skinItemModel(s *client.SkinItem) models.ItemSkin {
+	skinItem := models.ItemSkin{
+		ID:             s.ID,
+		Name:           safeGetString(s.Name, s.ID, "Name"),
+		MarketHashName: s.MarketHashName,
+		SkinId:         s.SkinId,
+		Image:          s.Image,
+		Stattrak:       s.Stattrak,
+		Souvenir:       s.Souvenir,
+	}
+	if _, ok := wearModel(&s.Wear); ok {
+		skinItem.WearId = &s.Wear.ID
+	}
+	// the phases share the skin and the market hash name, the phase tells the items apart for the markets listing it
+	if phase, ok := dopplerPhase(s.Phase, s.PaintIndex); ok {
+		skinItem.Phase = &phase
+	}
+	return skinItem
+}
+
+func agentModel(a *client.Agent) models.Agent {
+	return models.Agent{
+		ID:             a.ID,
+		Name:           a.Name.(string),
+		MarketHashName: a.MarketHashName,
+		CollectionId:   primaryCollection(a.Collections),
+		Image:          a.Image,
+		TeamId:         a.Team.ID,
+		RarityId:       a.Rarity.ID,
+	}
+}
+
+func patchModel(p *client.Patch) models.Patch {
+	return models.Patch{
+		ID:             p.ID,
+		Name:           p.Name.(string),
+		MarketHashName: p.MarketHashName,
+		Image:          p.Image,
+		RarityId:       p.Rarity.ID,
+	}
+}
+
+func charmModel(c *client.Charm) models.Charm {
+	return models.Charm{
+		ID:             c.ID,
+		Name:           c.Name.(string),
+		MarketHashName: c.MarketHashName,
+		Image:          c.Image,
+		RarityId:       c.Rarity.ID,
+		CollectionId:   primaryCollection(c.Collections),
+	}
+}
+
+func caseModel(c *client.Cases) models.Case {
+	return models.Case{
+		ID:    c.ID,
+		Name:  c.Name.(string),
+		Image: c.Image,
+		Type:  models.CaseType(c.Type),
+	}
+}
+
+func caseDropModel(caseID string, item *client.BaseItemInstance, rare bool) models.CaseDrop {
+	drop := models.CaseDrop{
+		CaseID: caseID,
+		ItemID: item.ID,
+		Name:   safeGetString(item.Name, item.ID, "Name"),
+		Image:  item.Image,
+		Rare:   rare,
+	}
+	if item.Rarity.ID != "" {
+		drop.RarityId = &item.Rarity.ID
+	}
+	return drop
+}
+
+func collectionSkinModel(collectionID string, s *client.CollectionSkins) models.CollectionSkin {
+	return models.CollectionSkin{
+		CollectionID: collectionID,
+		ItemID:       s.ID,
+		Name:         safeGetString(s.Name, s.ID, "Name"),
+		PaintIndex:   paintIndex(s.PaintIndex),
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+var (
+	itemColumns           = []string{"market_hash_name", "type"}
+	itemPropertiesColumns = []string{"item_id", "patch_id", "agent_id", "charm_id", "sticker_id", "skin_item_id", "case_id"}
+)
+
+// marketable entities of the registry, cases have no market hash name upstream so their name is used
+var itemSources = []struct {
+	itemType models.ItemType
+	model    any
+	column   string // expression of the market hash name
+	set      func(p *models.ItemProperties, id *string)
+}{
+	{models.SkinItem, &models.ItemSkin{}, "market_hash_name", func(p *models.ItemProperties, id *string) { p.SkinItemId = id }},
+	{models.StickerItem, &models.Sticker{}, "market_hash_name", func(p *models.ItemProperties, id *string) { p.StickerId = id }},
+	{models.PatchItem, &models.Patch{}, "market_hash_name", func(p *models.ItemProperties, id *string) { p.PatchId = id }},
+	{models.AgentItem, &models.Agent{}, "market_hash_name", func(p *models.ItemProperties, id *string) { p.AgentId = id }},
+	{models.CharmItem, &models.Charm{}, "market_hash_name", func(p *models.ItemProperties, id *string) { p.CharmId = id }},
+	{models.CaseItem, &models.Case{}, "name", func(p *models.ItemProperties, id *string) { p.CaseId = id }},
+}
+
+// SyncItems generates the item registry, one item per market hash name of the stored catalog
+// retired entities are kept since their market hash names still appear in price histories,
+// when several entities share a market hash name the first one in the order of itemSources wins
+func (r *Repository) SyncItems(tx *gorm.DB) (SyncResult, error) {
+	var items []models.Item
+	var props []models.ItemProperties
+	for _, source := range itemSources {
+		var rows []struct {
+			ID             string
+			MarketHashName string
+		}
+		if err := tx.Model(source.model).
+			Select("id, " + source.column + " AS market_hash_name").
+			Where(source.column + " <> ''").
+			Order("id").
+			Scan(&rows).Error; err != nil {
+			return SyncResult{}, err
+		}
+
+		for _, row := range rows {
+			items = append(items, models.Item{
+				ID:             row.ID,
+				MarketHashName: row.MarketHashName,
+				Type:           source.itemType,
+			})
+
+			id := row.ID
+			p := models.ItemProperties{ID: id, ItemID: id}
+			source.set(&p, &id)
+			props = append(props, p)
+		}
+	}
+	items = uniqueBy(items, func(i models.Item) string { return i.MarketHashName })
+	items = uniqueBy(items, func(i models.Item) string { return i.ID })
+
+	result, err := syncRows(items, func(i models.Item) string { return i.ID }, itemColumns, r.batchSize(), tx)
+	if err != nil {
+		return result, err
+	}
+
+	// items whose market hash name was already taken by another id were skipped by ON CONFLICT
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	stored, err := r.existingIDs(&models.Item{}, ids, tx)
+	if err != nil {
+		return result, err
+	}
+
+	var storedProps []models.ItemProperties
+	for _, p := range props {
+		if _, ok := stored[p.ID]; ok {
+			storedProps = append(storedProps, p)
+		}
+	}
+	storedProps = uniqueBy(storedProps, func(p models.ItemProperties) string { return p.ID })
+
+	if _, err := syncRows(storedProps, func(p models.ItemProperties) string { return p.ID }, itemPropertiesColumns, r.batchSize(), tx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ItemByMarketHashName returns the item with its properties and the entity they point at preloaded,
+// see models.ItemProperties.Entity, gorm.ErrRecordNotFound if there is no such item
+// the phases of the Doppler family share the item of their name, markethash.Resolver tells them apart
+func (r *Repository) ItemByMarketHashName(name string, tx *gorm.DB) (models.Item, error) {
+	var item models.Item
+	if err := tx.
+		Preload("Props.SkinItem.Skin.Weapon").
+		Preload("Props.SkinItem.Skin.Rarity").
+		Preload("Props.SkinItem.Wear").
+		Preload("Props.Sticker.Rarity").
+		Preload("Props.Sticker.Tournament").
+		Preload("Props.Sticker.Team").
+		Preload("Props.Sticker.Player").
+		Preload("Props.Patch.Rarity").
+		Preload("Props.Agent.Rarity").
+		Preload("Props.Agent.Collections").
+		Preload("Props.Charm.Rarity").
+		Preload("Props.Charm.Collections").
+		Preload("Props.Case.Drops").
+		Where("market_hash_name = ?", name).
+		First(&item).Error; err != nil {
+		return models.Item{}, err
+	}
+	return item, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// catalog tables whose rows are retired when they disappear upstream
+var retirable = []struct {
+	entity string
+	model  any
+}{
+	{"tournaments", &models.Tournament{}},
+	{"tournament_teams", &models.TournamentTeam{}},
+	{"players", &models.Player{}},
+	{"wears", &models.Wear{}},
+	{"rarities", &models.Rarity{}},
+	{"weapons", &models.Weapon{}},
+	{"collections", &models.Collection{}},
+	{"categories", &models.Category{}},
+	{"teams", &models.Team{}},
+	{"patterns", &models.Pattern{}},
+	{"skins", &models.Skin{}},
+	{"skin_phases", &models.SkinPhase{}},
+	{"item_skins", &models.ItemSkin{}},
+	{"stickers", &models.Sticker{}},
+	{"patches", &models.Patch{}},
+	{"agents", &models.Agent{}},
+	{"charms", &models.Charm{}},
+	{"cases", &models.Case{}},
+}
+
+// BeginRun creates a new run, every row written by the Upsert* methods afterwards is marked as seen in it
+func (r *Repository) BeginRun(tx *gorm.DB) (models.PopulateRun, error) {
+	run := models.PopulateRun{StartedAt: time.Now()}
+	if err := tx.Create(&run).Error; err != nil {
+		return models.PopulateRun{}, err
+	}
+	r.run = &run
+	return run, nil
+}
+
+// FinishRun retires every catalog row that was not seen since the run started
+// rows are never deleted, they get a retired_at timestamp and a Retirement record of the run
+// tables listed in skip are not checked, ex. because the endpoints writing them were not processed
+func (r *Repository) FinishRun(run *models.PopulateRun, skip []string, tx *gorm.DB) ([]models.Retirement, error) {
+	var retirements []models.Retirement
+	now := time.Now()
+
+	for _, table := range retirable {
+		if slices.Contains(skip, table.entity) {
+			continue
+		}
+
+		var ids []string
+		if err := tx.Model(table.model).
+			Where("last_seen < ? AND retired_at IS NULL", run.StartedAt).
+			Pluck("id::text", &ids).Error; err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		if err := tx.Model(table.model).
+			Where("last_seen < ? AND retired_at IS NULL", run.StartedAt).
+			Update("retired_at", now).Error; err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			retirements = append(retirements, models.Retirement{
+				RunID:     run.ID,
+				Entity:    table.entity,
+				EntityID:  id,
+				RetiredAt: now,
+			})
+		}
+	}
+
+	if len(retirements) > 0 {
+		if err := tx.CreateInBatches(&retirements, r.batchSize()).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	run.FinishedAt = &now
+	if err := tx.Model(run).Update("finished_at", now).Error; err != nil {
+		return nil, err
+	}
+	r.run = nil
+	return retirements, nil
+}
+
+// ListRetirements returns the rows retired by the given run
+func (r *Repository) ListRetirements(runID uint, tx *gorm.DB) ([]models.Retirement, error) {
+	var retirements []models.Retirement
+	if err := tx.Where("run_id = ?", runID).Order("entity, entity_id").Find(&retirements).Error; err != nil {
+		return nil, err
+	}
+	return retirements, nil
+}
+
+// RecordPayloads stores the digests of the endpoints read by the run
+func (r *Repository) RecordPayloads(runID uint, digests map[string]string, tx *gorm.DB) error {
+	var payloads []models.RunPayload
+	for endpoint, digest := range digests {
+		payloads = append(payloads, models.RunPayload{
+			RunID:    runID,
+			Endpoint: endpoint,
+			Digest:   digest,
+		})
+	}
+	return insertBatches(payloads, r.batchSize(), tx)
+}
+
+// LastPayloads returns the digests of the endpoints read by the last finished run, keyed by endpoint
+func (r *Repository) LastPayloads(tx *gorm.DB) (map[string]string, error) {
+	var run models.PopulateRun
+	if err := tx.Where("finished_at IS NOT NULL").Order("id DESC").First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	var payloads []models.RunPayload
+	if err := tx.Where("run_id = ?", run.ID).Find(&payloads).Error; err != nil {
+		return nil, err
+	}
+
+	digests := make(map[string]string, len(payloads))
+	for _, payload := range payloads {
+		digests[payload.Endpoint] = payload.Digest
+	}
+	return digests, nil
+}
+
+// markSeen sets last_seen of the rows to the start of the current run and revives retired ones
+// rows inserted during the run get the run start as first_seen too, it does nothing outside of a run
+func markSeen[T any, K comparable](r *Repository, rows []T, id func(T) K, tx *gorm.DB) error {
+	if r.run == nil || len(rows) == 0 {
+		return nil
+	}
+
+	ids := make([]K, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, id(row))
+	}
+
+	n := r.batchSize()
+	for start := 0; start < len(ids); start += n {
+		end := min(start+n, len(ids))
+		if err := tx.Model(new(T)).Where("id IN ?", ids[start:end]).Updates(map[string]any{
+			"first_seen": gorm.Expr("LEAST(first_seen, ?)", r.run.StartedAt),
+			"last_seen":  r.run.StartedAt,
+			"retired_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// stickerClass is what a sticker is, derived from the type and effect of the api and its name otherwise
+type stickerClass struct {
+	Kind   models.StickerKind
+	Effect models.StickerEffect
+	Player string // autographed player, empty unless Kind is AutographSticker
+}
+
+var stickerEffects = []models.StickerEffect{
+	models.HoloEffect,
+	models.FoilEffect,
+	models.GoldEffect,
+	models.GlitterEffect,
+	models.LenticularEffect,
+}
+
+// parseStickerName splits a name like "Sticker | s1mple (Gold) | Stockholm 2021"
+// in its subject "s1mple", the finish effect "Gold" and the event "Stockholm 2021"
+// the effect and event are empty when the name doesn't have them
+func parseStickerName(name string) (subject string, effect models.StickerEffect, event string) {
+	name = strings.TrimPrefix(name, "Sticker | ")
+	subject, event, _ = strings.Cut(name, " | ")
+
+	// the parentheses may hold more than the effect, ex. "(Foil, Champion)"
+	open := strings.LastIndex(subject, " (")
+	if open < 0 || !strings.HasSuffix(subject, ")") {
+		return subject, "", event
+	}
+	for _, part := range strings.Split(subject[open+2:len(subject)-1], ",") {
+		if e, ok := stickerEffect(strings.TrimSpace(part)); ok {
+			return subject[:open], e, event
+		}
+	}
+	return subject, "", event
+}
+
+// stickerEffect matches an effect case insensitively, "Holo-Foil" and similar are not distinguished
+func stickerEffect(s string) (models.StickerEffect, bool) {
+	for _, effect := range stickerEffects {
+		if strings.EqualFold(s, string(effect)) {
+			return effect, true
+		}
+	}
+	return "", false
+}
+
+func classifySticker(s *client.Sticker) stickerClass {
+	name, _ := s.Name.(string)
+	subject, effect, _ := parseStickerName(name)
+
+	var class stickerClass
+	if e, ok := stickerEffect(s.Effect); ok {
+		class.Effect = e
+	} else {
+		class.Effect = effect
+	}
+
+	switch strings.ToLower(s.Type) {
+	case "autograph":
+		class.Kind = models.AutographSticker
+	case "team":
+		class.Kind = models.TeamSticker
+	case "event":
+		class.Kind = models.EventSticker
+	default:
+		// older snapshots have no type, tournament stickers are told apart by their subject
+		switch {
+		case s.TournamentEvent == "":
+			class.Kind = models.RegularSticker
+		case s.TournamentTeam == "":
+			class.Kind = models.EventSticker
+		case strings.EqualFold(subject, s.TournamentTeam):
+			class.Kind = models.TeamSticker
+		default:
+			class.Kind = models.AutographSticker
+		}
+	}
+
+	if class.Kind == models.AutographSticker {
+		class.Player = subject
+	}
+	return class
+}
+
+// UpsertPlayers creates the players of the autograph stickers and links them to the teams they signed for
+// the teams are expected to be created already, players are returned keyed by name
+func (r *Repository) UpsertPlayers(s []client.Sticker, tot map[string]models.TournamentTeam, tx *gorm.DB) (map[string]models.Player, error) {
+	var players []models.Player
+	teamsOf := make(map[string][]uint32)
+	for _, sticker := range s {
+		class := classifySticker(&sticker)
+		if class.Player == "" {
+			continue
+		}
+		players = append(players, models.Player{Name: class.Player})
+		if team, ok := tot[sticker.TournamentTeam]; ok {
+			teamsOf[class.Player] = append(teamsOf[class.Player], team.ID)
+		}
+	}
+	players = uniqueBy(players, func(p models.Player) string { return p.Name })
+
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+
+	if err := insertBatches(players, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+
+	// ids of rows skipped by ON CONFLICT are not returned, read them back
+	var stored []models.Player
+	if err := tx.Where("name IN ?", names).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	if err := markSeen(r, stored, func(p models.Player) uint32 { return p.ID }, tx); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]models.Player, len(stored))
+	playerIDs := make([]uint32, 0, len(stored))
+	var playerTeams []models.PlayerTeam
+	for _, player := range stored {
+		byName[player.Name] = player
+		playerIDs = append(playerIDs, player.ID)
+		for _, teamID := range teamsOf[player.Name] {
+			playerTeams = append(playerTeams, models.PlayerTeam{
+				PlayerID:         player.ID,
+				TournamentTeamID: teamID,
+			})
+		}
+	}
+	playerTeams = uniqueBy(playerTeams, func(p models.PlayerTeam) string {
+		return fmt.Sprintf("%d-%d", p.PlayerID, p.TournamentTeamID)
+	})
+
+	if err := replaceAssociations(playerTeams, "player_id", playerIDs, r.batchSize(), tx); err != nil {
+		return nil, err
+	}
+	return byName, nil
+}
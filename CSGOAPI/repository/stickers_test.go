@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+func TestParseStickerName(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		effect  models.StickerEffect
+		event   string
+	}{
+		{"Sticker | s1mple (Gold) | Stockholm 2021", "s1mple", models.GoldEffect, "Stockholm 2021"},
+		{"Sticker | Natus Vincere (Holo) | Stockholm 2021", "Natus Vincere", models.HoloEffect, "Stockholm 2021"},
+		{"Sticker | PGL (Foil) | Stockholm 2021", "PGL", models.FoilEffect, "Stockholm 2021"},
+		{"Sticker | ZywOo (Glitter, Champion) | Paris 2023", "ZywOo", models.GlitterEffect, "Paris 2023"},
+		{"Sticker | Vitality (Holo, Champion) | Paris 2023", "Vitality", models.HoloEffect, "Paris 2023"},
+		{"Sticker | Team Liquid | Katowice 2019", "Team Liquid", "", "Katowice 2019"},
+		{"Sticker | Crown (Foil)", "Crown", models.FoilEffect, ""},
+		{"Sticker | Howling Dawn", "Howling Dawn", "", ""},
+		{"Sticker | Bolt Energy (Lenticular)", "Bolt Energy", models.LenticularEffect, ""},
+		{"Sticker | Boris (Glitter)", "Boris", models.GlitterEffect, ""},
+		// parentheses that aren't an effect stay in the subject
+		{"Sticker | Flick Shot (Champion)", "Flick Shot (Champion)", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, effect, event := parseStickerName(tt.name)
+			if subject != tt.subject || effect != tt.effect || event != tt.event {
+				t.Errorf("parseStickerName(%q) = %q, %q, %q, want %q, %q, %q",
+					tt.name, subject, effect, event, tt.subject, tt.effect, tt.event)
+			}
+		})
+	}
+}
+
+func TestClassifySticker(t *testing.T) {
+	sticker := func(name, event, team, kind, effect string) client.Sticker {
+		var s client.Sticker
+		s.Name = name
+		s.TournamentEvent = event
+		s.TournamentTeam = team
+		s.Type = kind
+		s.Effect = effect
+		return s
+	}
+
+	tests := []struct {
+		sticker client.Sticker
+		want    stickerClass
+	}{
+		// snapshots with the type and effect of the api
+		{sticker("Sticker | s1mple (Gold) | Stockholm 2021", "PGL Major Stockholm 2021", "Natus Vincere", "Autograph", "Gold"),
+			stickerClass{models.AutographSticker, models.GoldEffect, "s1mple"}},
+		{sticker("Sticker | Natus Vincere (Holo) | Stockholm 2021", "PGL Major Stockholm 2021", "Natus Vincere", "Team", "Holo"),
+			stickerClass{models.TeamSticker, models.HoloEffect, ""}},
+		{sticker("Sticker | PGL (Foil) | Stockholm 2021", "PGL Major Stockholm 2021", "", "Event", "Foil"),
+			stickerClass{models.EventSticker, models.FoilEffect, ""}},
+		{sticker("Sticker | ZywOo (Glitter, Champion) | Paris 2023", "BLAST.tv Paris 2023", "Team Vitality", "Autograph", "Glitter"),
+			stickerClass{models.AutographSticker, models.GlitterEffect, "ZywOo"}},
+		{sticker("Sticker | Crown (Foil)", "", "", "Other", "Foil"),
+			stickerClass{models.RegularSticker, models.FoilEffect, ""}},
+		// the api effect wins over the name
+		{sticker("Sticker | Howling Dawn", "", "", "Other", "Holo"),
+			stickerClass{models.RegularSticker, models.HoloEffect, ""}},
+
+		// older snapshots without type and effect fall back to the name and the tournament fields
+		{sticker("Sticker | device (Holo) | Katowice 2019", "IEM Katowice 2019", "Astralis", "", ""),
+			stickerClass{models.AutographSticker, models.HoloEffect, "device"}},
+		{sticker("Sticker | Astralis (Gold) | Katowice 2019", "IEM Katowice 2019", "Astralis", "", ""),
+			stickerClass{models.TeamSticker, models.GoldEffect, ""}},
+		{sticker("Sticker | IEM (Foil) | Katowice 2019", "IEM Katowice 2019", "", "", ""),
+			stickerClass{models.EventSticker, models.FoilEffect, ""}},
+		{sticker("Sticker | Boris (Glitter)", "", "", "", ""),
+			stickerClass{models.RegularSticker, models.GlitterEffect, ""}},
+		{sticker("Sticker | Howling Dawn", "", "", "", ""),
+			stickerClass{models.RegularSticker, "", ""}},
+	}
+	for _, tt := range tests {
+		name, _ := tt.sticker.Name.(string)
+		t.Run(name, func(t *testing.T) {
+			if got := classifySticker(&tt.sticker); got != tt.want {
+				t.Errorf("classifySticker(%q) = %+v, want %+v", name, got, tt.want)
+			}
+		})
+	}
+}
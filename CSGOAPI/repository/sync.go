@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SyncResult counts what a sync did with the rows of one entity
+type SyncResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+func (s SyncResult) String() string {
+	return fmt.Sprintf("%d inserted, %d updated, %d unchanged", s.Inserted, s.Updated, s.Unchanged)
+}
+
+// Add sums up two results, used when an entity is synced in several steps
+func (s SyncResult) Add(o SyncResult) SyncResult {
+	return SyncResult{
+		Inserted:  s.Inserted + o.Inserted,
+		Updated:   s.Updated + o.Updated,
+		Unchanged: s.Unchanged + o.Unchanged,
+	}
+}
+
+// columns compared and updated by the sync of each catalog entity
+// columns that are not set from the api (ex. the collection of a sticker) are left out so they are never reset
+var (
+	skinColumns       = []string{"name", "image", "weapon_id", "rarity_id", "paint_index", "min_float", "max_float", "stattrak", "souvenir", "collection_id", "category_id", "team_id", "pattern_id"}
+	stickerColumns    = []string{"name", "image", "rarity_id", "case_id", "tournament_id", "team_id", "kind", "effect", "player_id", "market_hash_name"}
+	skinPhaseColumns  = []string{"skin_id", "phase", "paint_index", "image"}
+	skinItemColumns   = []string{"name", "market_hash_name", "image", "stattrak", "souvenir", "skin_id", "wear_id", "phase"}
+	agentColumns      = []string{"name", "market_hash_name", "image", "rarity_id", "collection_id", "team_id"}
+	charmColumns      = []string{"name", "market_hash_name", "image", "rarity_id", "collection_id"}
+	patchColumns      = []string{"name", "market_hash_name", "image", "rarity_id"}
+	weaponColumns     = []string{"name", "def_index"}
+	skinWeaponColumns = append([]string{"class", "terrorist", "counter_terrorist", "special"}, weaponColumns...)
+	crateColumns      = []string{"name", "image"}
+	collectionColumns = []string{"name", "image"}
+)
+
+var schemaCache sync.Map
+
+// syncRows inserts the rows that don't exist yet and updates the given columns of the rows
+// whose stored values differ, rows must be unique by their "id" primary key
+func syncRows[T any](rows []T, id func(T) string, columns []string, n int, tx *gorm.DB) (SyncResult, error) {
+	var result SyncResult
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	s, err := schema.Parse(new(T), &schemaCache, tx.NamingStrategy)
+	if err != nil {
+		return result, err
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, id(row))
+	}
+
+	// load the stored rows in chunks to stay below the parameter limit of postgres
+	stored := make(map[string]T, len(rows))
+	for start := 0; start < len(ids); start += n {
+		end := min(start+n, len(ids))
+
+		var chunk []T
+		if err := tx.Where("id IN ?", ids[start:end]).Find(&chunk).Error; err != nil {
+			return result, err
+		}
+		for _, row := range chunk {
+			stored[id(row)] = row
+		}
+	}
+
+	var inserts, updates []T
+	for _, row := range rows {
+		old, ok := stored[id(row)]
+		switch {
+		case !ok:
+			inserts = append(inserts, row)
+		case changed(s, old, row, columns):
+			updates = append(updates, row)
+		default:
+			result.Unchanged++
+		}
+	}
+
+	if err := insertBatches(inserts, n, tx); err != nil {
+		return result, err
+	}
+	result.Inserted = len(inserts)
+
+	if len(updates) > 0 {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns(columns),
+		}).Omit(clause.Associations).CreateInBatches(updates, n).Error; err != nil {
+			return result, err
+		}
+	}
+	result.Updated = len(updates)
+
+	return result, nil
+}
+
+// changed reports whether any of the columns differs between the stored and the fetched row
+func changed[T any](s *schema.Schema, old, new T, columns []string) bool {
+	ctx := context.Background()
+	oldValue := reflect.ValueOf(&old).Elem()
+	newValue := reflect.ValueOf(&new).Elem()
+
+	for _, column := range columns {
+		field := s.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		o, _ := field.ValueOf(ctx, oldValue)
+		n, _ := field.ValueOf(ctx, newValue)
+		if !reflect.DeepEqual(o, n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// TournamentTeams returns the teams that had stickers at the tournament, ex. "2021 PGL Stockholm"
+func (r *Repository) TournamentTeams(tournament string, tx *gorm.DB) ([]models.TournamentTeam, error) {
+	var teams []models.TournamentTeam
+	if err := tx.Joins("JOIN tournament_team_relations ttr ON ttr.tournament_team_id = tournament_teams.id").
+		Joins("JOIN tournaments t ON t.id = ttr.tournament_id").
+		Where("t.name = ?", tournament).
+		Order("tournament_teams.team").
+		Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// TeamTournaments returns the tournaments the team had stickers at, in the order they were first stored
+func (r *Repository) TeamTournaments(team string, tx *gorm.DB) ([]models.Tournament, error) {
+	var tournaments []models.Tournament
+	if err := tx.Joins("JOIN tournament_team_relations ttr ON ttr.tournament_id = tournaments.id").
+		Joins("JOIN tournament_teams tt ON tt.id = ttr.tournament_team_id").
+		Where("tt.team = ?", team).
+		Order("tournaments.id").
+		Find(&tournaments).Error; err != nil {
+		return nil, err
+	}
+	return tournaments, nil
+}
+
+// TeamStickers returns the stickers of the team across all tournaments with their tournament preloaded
+func (r *Repository) TeamStickers(team string, tx *gorm.DB) ([]models.Sticker, error) {
+	var stickers []models.Sticker
+	if err := tx.Preload("Tournament").Preload("Team").
+		Joins("JOIN tournament_teams tt ON tt.id = stickers.team_id").
+		Where("tt.team = ?", team).
+		Order("stickers.tournament_id, stickers.name").
+		Find(&stickers).Error; err != nil {
+		return nil, err
+	}
+	return stickers, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// catalog tables that can be translated with the column holding their english name
+var translatable = map[string]string{
+	"skins":       "name",
+	"item_skins":  "name",
+	"stickers":    "name",
+	"agents":      "name",
+	"patches":     "name",
+	"charms":      "name",
+	"cases":       "name",
+	"collections": "name",
+	"rarities":    "name",
+	"weapons":     "name",
+	"categories":  "name",
+	"patterns":    "name",
+	"teams":       "name",
+	"wears":       "name",
+}
+
+// UpsertTranslations creates the translations or replaces their name
+func (r *Repository) UpsertTranslations(t []models.Translation, tx *gorm.DB) ([]models.Translation, error) {
+	t = uniqueBy(t, func(t models.Translation) string { return t.Entity + "-" + t.EntityID + "-" + t.Locale })
+	if len(t) == 0 {
+		return t, nil
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity"}, {Name: "entity_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}).CreateInBatches(t, r.batchSize()).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Names returns the names of the rows of entity in the locale keyed by id
+// rows without a translation fall back to their english name, unknown ids are left out
+func (r *Repository) Names(entity string, ids []string, locale string, tx *gorm.DB) (map[string]string, error) {
+	column, ok := translatable[entity]
+	if !ok {
+		return nil, fmt.Errorf("%s can't be translated", entity)
+	}
+
+	var rows []struct {
+		ID   string
+		Name string
+	}
+	// entity and column come from the translatable list, never from the caller
+	if err := tx.Table(entity+" AS e").
+		Select("e.id, COALESCE(t.name, e."+column+"::text) AS name").
+		Joins("LEFT JOIN translations t ON t.entity = ? AND t.entity_id = e.id AND t.locale = ?", entity, locale).
+		Where("e.id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+// Name returns the name of a row in the locale, falling back to its english name
+func (r *Repository) Name(entity, id, locale string, tx *gorm.DB) (string, error) {
+	names, err := r.Names(entity, []string{id}, locale, tx)
+	if err != nil {
+		return "", err
+	}
+	name, ok := names[id]
+	if !ok {
+		return "", gorm.ErrRecordNotFound
+	}
+	return name, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// WeaponByDefIndex returns the weapon with the item definition index of the game
+func (r *Repository) WeaponByDefIndex(defIndex uint16, tx *gorm.DB) (models.Weapon, error) {
+	var weapon models.Weapon
+	if err := tx.Where("def_index = ?", defIndex).First(&weapon).Error; err != nil {
+		return models.Weapon{}, err
+	}
+	return weapon, nil
+}
+
+// SkinByDefIndex returns the skin of the weapon with the paint index, as found in inspect links and gen codes
+// vanilla knives and gloves have the paint index 0, the paint index of any phase of a Doppler family skin matches it
+func (r *Repository) SkinByDefIndex(defIndex, paintIndex uint16, tx *gorm.DB) (models.Skin, error) {
+	var skin models.Skin
+	if err := tx.Preload("Weapon").Preload("Rarity").Preload("Phases").
+		Joins("JOIN weapons w ON w.id = skins.weapon_id").
+		Where("w.def_index = ? AND (skins.paint_index = ? OR skins.id IN (?))", defIndex, paintIndex,
+			tx.Model(&models.SkinPhase{}).Select("skin_id").Where("paint_index = ?", paintIndex)).
+		First(&skin).Error; err != nil {
+		return models.Skin{}, err
+	}
+	return skin, nil
+}
+
+// WeaponsByClass returns the weapons of a class, ex. models.SniperClass, ordered by def index
+func (r *Repository) WeaponsByClass(class models.WeaponClass, tx *gorm.DB) ([]models.Weapon, error) {
+	var weapons []models.Weapon
+	if err := tx.Where("class = ?", class).Order("def_index").Find(&weapons).Error; err != nil {
+		return nil, err
+	}
+	return weapons, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/wear"
+	"gorm.io/gorm"
+)
+
+// WearMismatch is a skin whose wears in skin_wears differ from the ones its float range can reach
+type WearMismatch struct {
+	SkinID      string
+	Name        string
+	MinFloat    float64
+	MaxFloat    float64
+	Missing     []models.WearType // reachable but not listed upstream
+	Unreachable []models.WearType // listed upstream but outside of the float range
+}
+
+func (m WearMismatch) String() string {
+	return fmt.Sprintf("%s (%s) with floats %v to %v: missing %v, unreachable %v", m.Name, m.SkinID, m.MinFloat, m.MaxFloat, m.Missing, m.Unreachable)
+}
+
+// CheckSkinWears compares the wears stored for every skin with the ones derived from its float range
+// skins without any wear, such as vanilla knives, are not checked
+func (r *Repository) CheckSkinWears(tx *gorm.DB) ([]WearMismatch, error) {
+	var rows []struct {
+		SkinID string
+		Wear   string
+	}
+	if err := tx.Table("skin_wears AS sw").
+		Select("sw.skin_id, w.name::text AS wear").
+		Joins("JOIN wears w ON w.id = sw.wear_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	listed := make(map[string][]models.WearType)
+	for _, row := range rows {
+		listed[row.SkinID] = append(listed[row.SkinID], models.WearType(row.Wear))
+	}
+
+	var skins []models.Skin
+	if err := tx.Select("id", "name", "min_float", "max_float").
+		Where("retired_at IS NULL").
+		Order("id").
+		Find(&skins).Error; err != nil {
+		return nil, err
+	}
+
+	var mismatches []WearMismatch
+	for _, skin := range skins {
+		wears, ok := listed[skin.ID]
+		if !ok {
+			continue
+		}
+		reachable := wear.Reachable(skin.MinFloat, skin.MaxFloat)
+
+		mismatch := WearMismatch{SkinID: skin.ID, Name: skin.Name, MinFloat: skin.MinFloat, MaxFloat: skin.MaxFloat}
+		for _, w := range reachable {
+			if !slices.Contains(wears, w) {
+				mismatch.Missing = append(mismatch.Missing, w)
+			}
+		}
+		for _, w := range wears {
+			if !slices.Contains(reachable, w) {
+				mismatch.Unreachable = append(mismatch.Unreachable, w)
+			}
+		}
+		if len(mismatch.Missing) > 0 || len(mismatch.Unreachable) > 0 {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,108 @@
+package CSGOAPI
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// processLocale fetches the api in the locale and stores the names as translations
+func (p *Populator) processLocale(ctx context.Context, locale string) error {
+	if locale == models.DefaultLocale {
+		return nil
+	}
+
+	source, err := p.c.Localized(locale)
+	if err != nil {
+		return err
+	}
+
+	data, err := p.fetchData(ctx, source)
+	if err != nil {
+		return fmt.Errorf("fetching %s: %w", locale, err)
+	}
+
+	translations := collectTranslations(locale, data)
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := p.r.UpsertTranslations(translations, tx)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored %d %s translations\n", len(translations), locale)
+	return nil
+}
+
+// collectTranslations gathers the names of every entity in the localized data
+func collectTranslations(locale string, data *FetchedData) []models.Translation {
+	var translations []models.Translation
+	add := func(entity, id string, name json.Token) {
+		// some names are missing or not strings upstream
+		if n, ok := name.(string); ok && id != "" {
+			translations = append(translations, models.Translation{
+				Entity:   entity,
+				EntityID: id,
+				Locale:   locale,
+				Name:     n,
+			})
+		}
+	}
+	addCollections := func(c []client.CollectionResp) {
+		for _, collection := range c {
+			add("collections", collection.ID, collection.Name)
+		}
+	}
+	addCrates := func(c []client.Crate) {
+		for _, crate := range c {
+			add("cases", crate.ID, crate.Name)
+		}
+	}
+
+	for _, skin := range data.Skins {
+		add("skins", skin.ID, skin.Name)
+		add("rarities", skin.Rarity.ID, skin.Rarity.Name)
+		add("weapons", skin.Weapon.ID, skin.Weapon.Name)
+		add("categories", skin.Category.ID, skin.Category.Name)
+		add("patterns", skin.Pattern.ID, skin.Pattern.Name)
+		add("teams", skin.Team.ID, skin.Team.Name)
+		for _, wear := range skin.Wears {
+			add("wears", wear.ID, wear.Name)
+		}
+		addCollections(skin.Collections)
+		addCrates(skin.Crates)
+	}
+	for _, skin := range data.SkinItems {
+		add("item_skins", skin.ID, skin.Name)
+	}
+	for _, sticker := range data.Stickers {
+		add("stickers", sticker.ID, sticker.Name)
+		add("rarities", sticker.Rarity.ID, sticker.Rarity.Name)
+		addCrates(sticker.Crate)
+	}
+	for _, agent := range data.Agents {
+		add("agents", agent.ID, agent.Name)
+		add("teams", agent.Team.ID, agent.Team.Name)
+		addCollections(agent.Collections)
+	}
+	for _, patch := range data.Patches {
+		add("patches", patch.ID, patch.Name)
+	}
+	for _, charm := range data.Charms {
+		add("charms", charm.ID, charm.Name)
+		addCollections(charm.Collections)
+	}
+	for _, crate := range data.Cases {
+		add("cases", crate.ID, crate.Name)
+	}
+	for _, collection := range data.Collections {
+		add("collections", collection.ID, collection.Name)
+		addCrates(collection.Crates)
+	}
+	return translations
+}
@@ -0,0 +1,365 @@
+// Package caseev computes the expected value of opening cases from their drops, the odds of the rarity tiers
+// and the prices of the items
+package caseev
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/prices"
+	"github.com/massimomarsiglia/cs-skins-market-models/wear"
+	"gorm.io/gorm"
+)
+
+// StatTrakChance is the chance of a skin of a weapon case to be StatTrak when the skin can be
+const StatTrakChance = 0.1
+
+// weights of the rarity tiers, the odds published for weapon cases with the other tiers
+// following the same 1 in 5 ratio, the weights of the tiers of a case are normalized
+var tierWeights = map[string]float64{
+	"common":    19.98,  // Consumer Grade
+	"uncommon":  3.996,  // Industrial Grade
+	"rare":      0.7992, // Mil-Spec, High Grade
+	"mythical":  0.1598, // Restricted, Remarkable
+	"legendary": 0.032,  // Classified, Exotic
+	"ancient":   0.0064, // Covert, Extraordinary
+}
+
+// RareSpecialWeight is the weight of the rare special items pool, ex. knives and gloves
+const RareSpecialWeight = 0.0026
+
+// RareSpecialTier names the tier of the rare special items
+const RareSpecialTier = "rare special"
+
+var ErrNoDrops = errors.New("case has no drops")
+
+// Options of the calculation, prices are in minor units of the currency of the marketplace
+type Options struct {
+	Marketplace string        // prices are read from this marketplace, see prices.Marketplaces
+	KeyCost     int64         // cost of the key opening weapon cases
+	SellerFee   float64       // fraction of the sale price kept by the marketplace, ex. 0.13 on steam
+	MaxAge      time.Duration // prices observed earlier are ignored, 0 keeps every price
+}
+
+// DefaultOptions price with the steam market of the last week and a key at 2.49 USD
+var DefaultOptions = Options{Marketplace: "steam", KeyCost: 249, SellerFee: 0.13, MaxAge: 7 * 24 * time.Hour}
+
+// Tier is the chance to unbox a rarity tier of a case
+type Tier struct {
+	Rarity string // rarity id, or RareSpecialTier
+	Odds   float64
+	Drops  int
+}
+
+// Drop is the expected value of a drop of a case
+type Drop struct {
+	Drop     models.CaseDrop
+	Tier     string
+	Odds     float64 // chance to unbox the drop
+	Value    float64 // expected price of the drop once unboxed over its priced wears and StatTrak
+	Coverage float64 // fraction of the outcomes of the drop with a price
+}
+
+// Result is the expected value of opening a case once
+type Result struct {
+	Case     models.Case
+	Currency string
+
+	CasePrice int64 // 0 when the case has no price, see Priced
+	Priced    bool
+	Cost      int64 // case and key
+
+	Tiers []Tier
+	Drops []Drop
+
+	EV       float64 // expected price of the unboxed item, after the seller fee
+	ROI      float64 // (EV - Cost) / Cost, 0 when the cost is unknown
+	Coverage float64 // chance that the unboxed item has a price, EV leaves the others out
+}
+
+// Calculate returns the expected value of opening the case
+func Calculate(caseID string, o Options, tx *gorm.DB) (Result, error) {
+	results, err := calculate(o, tx.Where("id = ?", caseID))
+	if err != nil {
+		return Result{}, err
+	}
+	if len(results) == 0 {
+		return Result{}, fmt.Errorf("%w: %s", ErrNoDrops, caseID)
+	}
+	return results[0], nil
+}
+
+// CalculateAll returns the expected value of every case with drops except graffiti boxes, sorted by ROI descending,
+// cases without a price come last
+func CalculateAll(o Options, tx *gorm.DB) ([]Result, error) {
+	results, err := calculate(o, tx.Where("type <> ? OR type IS NULL", models.GraffitiBox))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(results, func(a, b Result) int {
+		if a.Priced != b.Priced {
+			if a.Priced {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.ROI, a.ROI)
+	})
+	return results, nil
+}
+
+type variantKey struct {
+	skinID   string
+	wear     models.WearType
+	stattrak bool
+	souvenir bool
+}
+
+// calculator holds what the cases need: the item skins of the dropped skins and the prices
+type calculator struct {
+	o        Options
+	currency string
+	variants map[variantKey][]string // item skin ids, several for the phases of a Doppler
+	prices   map[string]int64
+}
+
+func calculate(o Options, query *gorm.DB) ([]Result, error) {
+	c, cases, err := load(o, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(cases))
+	for _, cs := range cases {
+		if len(cs.Drops) == 0 {
+			continue
+		}
+		results = append(results, c.result(cs))
+	}
+	return results, nil
+}
+
+// load reads the cases of the query with their drops, sorted, and what the calculator needs to value them
+func load(o Options, query *gorm.DB) (*calculator, []models.Case, error) {
+	tx := query.Session(&gorm.Session{NewDB: true})
+
+	var cases []models.Case
+	if err := query.Preload("Drops.Skin").Order("name").Find(&cases).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var marketplace models.Marketplace
+	if err := tx.First(&marketplace, "id = ?", o.Marketplace).Error; err != nil {
+		return nil, nil, fmt.Errorf("marketplace %s: %w", o.Marketplace, err)
+	}
+
+	c := &calculator{o: o, currency: marketplace.Currency, variants: make(map[variantKey][]string)}
+	ids := make([]string, 0, len(cases))
+	var skinIDs []string
+	for _, cs := range cases {
+		ids = append(ids, cs.ID)
+		slices.SortFunc(cs.Drops, func(a, b models.CaseDrop) int { return strings.Compare(a.ItemID, b.ItemID) })
+		for _, drop := range cs.Drops {
+			switch {
+			case drop.SkinId != nil:
+				skinIDs = append(skinIDs, *drop.SkinId)
+			case drop.StickerId != nil:
+				ids = append(ids, *drop.StickerId)
+			case drop.AgentId != nil:
+				ids = append(ids, *drop.AgentId)
+			case drop.CharmId != nil:
+				ids = append(ids, *drop.CharmId)
+			}
+		}
+	}
+
+	for start := 0; start < len(skinIDs); start += prices.DefaultBatchSize {
+		end := min(start+prices.DefaultBatchSize, len(skinIDs))
+
+		var items []models.ItemSkin
+		if err := tx.Preload("Wear").Where("skin_id IN ?", skinIDs[start:end]).Order("id").Find(&items).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, item := range items {
+			key := variantKey{skinID: item.SkinId, stattrak: item.Stattrak, souvenir: item.Souvenir}
+			if item.Wear != nil {
+				key.wear = item.Wear.Name
+			}
+			c.variants[key] = append(c.variants[key], item.ID)
+			ids = append(ids, item.ID)
+		}
+	}
+
+	var since time.Time
+	if o.MaxAge > 0 {
+		since = time.Now().Add(-o.MaxAge)
+	}
+	latest, err := prices.NewRepository().LatestPrices(o.Marketplace, ids, since, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.prices = make(map[string]int64, len(latest))
+	for id, observation := range latest {
+		if price, ok := prices.Price(&observation); ok {
+			c.prices[id] = price
+		}
+	}
+	return c, cases, nil
+}
+
+// groupTiers groups the drops of a case by tier, the tiers are sorted by descending weight
+// and drops of unknown rarity are left out since they can't be given odds
+func groupTiers(cs *models.Case) ([]string, map[string][]models.CaseDrop, float64) {
+	drops := make(map[string][]models.CaseDrop)
+	var total float64
+	for _, drop := range cs.Drops {
+		tier, ok := tierOf(&drop)
+		if !ok {
+			continue
+		}
+		if len(drops[tier]) == 0 {
+			total += weight(tier)
+		}
+		drops[tier] = append(drops[tier], drop)
+	}
+	return slices.SortedFunc(maps.Keys(drops), func(a, b string) int { return cmp.Compare(weight(b), weight(a)) }), drops, total
+}
+
+// tierOf returns the tier of a drop, false for drops whose rarity is unknown
+func tierOf(d *models.CaseDrop) (string, bool) {
+	if d.Rare {
+		return RareSpecialTier, true
+	}
+	if d.RarityId == nil {
+		return "", false
+	}
+	rarity := strings.TrimPrefix(*d.RarityId, "rarity_")
+	rarity = strings.TrimSuffix(strings.TrimSuffix(rarity, "_weapon"), "_character")
+	_, ok := tierWeights[rarity]
+	return rarity, ok
+}
+
+func weight(tier string) float64 {
+	if tier == RareSpecialTier {
+		return RareSpecialWeight
+	}
+	return tierWeights[tier]
+}
+
+func (c *calculator) result(cs models.Case) Result {
+	result := Result{Case: cs, Currency: c.currency}
+
+	tiers, drops, total := groupTiers(&cs)
+	for _, tier := range tiers {
+		tierDrops := drops[tier]
+		odds := weight(tier) / total
+		result.Tiers = append(result.Tiers, Tier{Rarity: tier, Odds: odds, Drops: len(tierDrops)})
+
+		// the drops of a tier are equally likely
+		for _, drop := range tierDrops {
+			d := Drop{Drop: drop, Tier: tier, Odds: odds / float64(len(tierDrops))}
+			d.Value, d.Coverage = c.value(&drop, cs.Type)
+			result.Drops = append(result.Drops, d)
+
+			result.EV += d.Odds * d.Value
+			result.Coverage += d.Odds * d.Coverage
+		}
+	}
+	slices.SortStableFunc(result.Drops, func(a, b Drop) int { return cmp.Compare(b.Odds*b.Value, a.Odds*a.Value) })
+	result.EV *= 1 - c.o.SellerFee
+
+	result.CasePrice, result.Priced = c.prices[cs.ID]
+	result.Cost = c.cost(&cs)
+	if result.Priced && result.Cost > 0 {
+		result.ROI = (result.EV - float64(result.Cost)) / float64(result.Cost)
+	}
+	return result
+}
+
+// cost is the price of the case and of the key opening it, capsules and souvenir packages open without a key
+func (c *calculator) cost(cs *models.Case) int64 {
+	cost := c.prices[cs.ID]
+	if cs.Type == models.WeaponCase {
+		cost += c.o.KeyCost
+	}
+	return cost
+}
+
+// value returns the expected price of a drop and the fraction of its outcomes that have a price
+func (c *calculator) value(d *models.CaseDrop, caseType models.CaseType) (float64, float64) {
+	var id *string
+	switch {
+	case d.SkinId != nil && d.Skin != nil:
+		return c.skinValue(d.Skin, caseType)
+	case d.StickerId != nil:
+		id = d.StickerId
+	case d.AgentId != nil:
+		id = d.AgentId
+	case d.CharmId != nil:
+		id = d.CharmId
+	default:
+		return 0, 0
+	}
+	if price, ok := c.prices[*id]; ok {
+		return float64(price), 1
+	}
+	return 0, 0
+}
+
+// skinValue averages the prices of the skin over its wears, implied by its float range, and StatTrak
+// the phases of a Doppler are taken as equally likely
+func (c *calculator) skinValue(s *models.Skin, caseType models.CaseType) (float64, float64) {
+	souvenir := caseType == models.SouvenirPackage
+	statTrak := 0.0
+	if s.Stattrak && caseType == models.WeaponCase {
+		statTrak = StatTrakChance
+	}
+
+	wears := wear.Distribution(s.MinFloat, s.MaxFloat)
+	// vanilla knives have no wear
+	if _, ok := c.variants[variantKey{skinID: s.ID, souvenir: souvenir}]; ok {
+		wears = []wear.Odds{{Odds: 1}}
+	}
+
+	var value, coverage float64
+	for _, st := range []struct {
+		stattrak bool
+		odds     float64
+	}{{false, 1 - statTrak}, {true, statTrak}} {
+		if st.odds == 0 {
+			continue
+		}
+		for _, w := range wears {
+			price, ok := c.variantPrice(c.variants[variantKey{skinID: s.ID, wear: w.Wear, stattrak: st.stattrak, souvenir: souvenir}])
+			if !ok {
+				continue
+			}
+			value += st.odds * w.Odds * price
+			coverage += st.odds * w.Odds
+		}
+	}
+	return value, coverage
+}
+
+// variantPrice returns the mean price of the priced item skins of a variant, the phases of a Doppler
+// share the item of their market hash name so only one of them has a price
+func (c *calculator) variantPrice(ids []string) (float64, bool) {
+	var sum float64
+	var priced int
+	for _, id := range ids {
+		if price, ok := c.prices[id]; ok {
+			sum += float64(price)
+			priced++
+		}
+	}
+	if priced == 0 {
+		return 0, false
+	}
+	return sum / float64(priced), true
+}
@@ -0,0 +1,206 @@
+package caseev
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/wear"
+	"gorm.io/gorm"
+)
+
+var ErrUnpricedCase = errors.New("case has no price")
+
+// SimulationOptions of a simulation, the same seed always gives the same result for the same data
+type SimulationOptions struct {
+	Opens  int    // cases opened per trial
+	Trials int    // number of times the opens are repeated
+	Seed   uint64 // seed of the random source
+}
+
+// Opening is an unboxed item
+type Opening struct {
+	Drop     models.CaseDrop
+	Tier     string
+	ItemID   string          // the item skin for skins, the catalog row otherwise, empty when the drop isn't resolved
+	Float    *float64        // nil unless the drop is a skin
+	Wear     models.WearType // empty for vanilla knives and drops that aren't skins
+	StatTrak bool
+	Price    int64 // sale price after the seller fee, 0 when unpriced
+	Priced   bool
+}
+
+// Simulation is the outcome of the trials, profits are in minor units of the currency of the marketplace
+type Simulation struct {
+	Case     models.Case
+	Currency string
+	Options  SimulationOptions
+	Cost     int64 // of a single opening, see Result.Cost
+
+	Profits   []float64 // profit of each trial, sorted ascending
+	Mean      float64
+	BreakEven float64 // chance of a trial to make a profit or break even
+	Unpriced  int     // openings of all trials whose item has no price, they are counted as worthless
+}
+
+// Percentile returns the profit of the trials at p, from 0 to 100, interpolating between trials
+func (s *Simulation) Percentile(p float64) float64 {
+	if len(s.Profits) == 0 {
+		return 0
+	}
+	rank := math.Max(0, math.Min(1, p/100)) * float64(len(s.Profits)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return s.Profits[lower] + (s.Profits[upper]-s.Profits[lower])*(rank-float64(lower))
+}
+
+// Simulate opens the case Opens times per trial with the drop weights used by Calculate, floats are drawn
+// uniformly in the float range of the skins and StatTrak with StatTrakChance, the phases of a Doppler are equally likely
+func Simulate(caseID string, o Options, s SimulationOptions, tx *gorm.DB) (Simulation, error) {
+	if s.Opens <= 0 || s.Trials <= 0 {
+		return Simulation{}, fmt.Errorf("invalid simulation of %d trials of %d opens", s.Trials, s.Opens)
+	}
+
+	c, cases, err := load(o, tx.Where("id = ?", caseID))
+	if err != nil {
+		return Simulation{}, err
+	}
+	if len(cases) == 0 {
+		return Simulation{}, fmt.Errorf("%w: %s", ErrNoDrops, caseID)
+	}
+	return c.simulate(cases[0], s)
+}
+
+// simulate runs the trials of a loaded case, the options must be valid
+func (c *calculator) simulate(cs models.Case, s SimulationOptions) (Simulation, error) {
+	if len(cs.Drops) == 0 {
+		return Simulation{}, fmt.Errorf("%w: %s", ErrNoDrops, cs.ID)
+	}
+	if _, ok := c.prices[cs.ID]; !ok {
+		return Simulation{}, fmt.Errorf("%w: %s on %s", ErrUnpricedCase, cs.Name, c.o.Marketplace)
+	}
+
+	sim := Simulation{Case: cs, Currency: c.currency, Options: s, Cost: c.cost(&cs)}
+	opener := c.opener(&cs, rand.New(rand.NewPCG(s.Seed, s.Seed)))
+	if opener == nil {
+		return Simulation{}, fmt.Errorf("%w: %s has no drop of a known rarity", ErrNoDrops, cs.Name)
+	}
+
+	sim.Profits = make([]float64, 0, s.Trials)
+	var breakEven int
+	for range s.Trials {
+		profit := -float64(sim.Cost) * float64(s.Opens)
+		for range s.Opens {
+			opening := opener.open()
+			if !opening.Priced {
+				sim.Unpriced++
+			}
+			profit += float64(opening.Price)
+		}
+		if profit >= 0 {
+			breakEven++
+		}
+		sim.Profits = append(sim.Profits, profit)
+		sim.Mean += profit / float64(s.Trials)
+	}
+	slices.Sort(sim.Profits)
+	sim.BreakEven = float64(breakEven) / float64(s.Trials)
+	return sim, nil
+}
+
+// opener opens a case with a random source
+type opener struct {
+	c       *calculator
+	cs      *models.Case
+	rng     *rand.Rand
+	tiers   []string
+	weights []float64 // cumulative
+	drops   map[string][]models.CaseDrop
+}
+
+// opener returns nil when no drop of the case has a known rarity
+func (c *calculator) opener(cs *models.Case, rng *rand.Rand) *opener {
+	tiers, drops, total := groupTiers(cs)
+	if len(tiers) == 0 {
+		return nil
+	}
+	o := &opener{c: c, cs: cs, rng: rng, tiers: tiers, drops: drops}
+	var sum float64
+	for _, tier := range tiers {
+		sum += weight(tier) / total
+		o.weights = append(o.weights, sum)
+	}
+	return o
+}
+
+// open unboxes an item
+func (o *opener) open() Opening {
+	// the last tier also takes what rounding left out
+	tier := o.tiers[len(o.tiers)-1]
+	roll := o.rng.Float64()
+	for i, w := range o.weights {
+		if roll < w {
+			tier = o.tiers[i]
+			break
+		}
+	}
+	drops := o.drops[tier]
+	opening := Opening{Drop: drops[o.rng.IntN(len(drops))], Tier: tier}
+
+	var price float64
+	switch d := &opening.Drop; {
+	case d.SkinId != nil && d.Skin != nil:
+		price, opening.Priced = o.openSkin(&opening, d.Skin)
+	case d.StickerId != nil:
+		price, opening.Priced = o.c.itemPrice(&opening, *d.StickerId)
+	case d.AgentId != nil:
+		price, opening.Priced = o.c.itemPrice(&opening, *d.AgentId)
+	case d.CharmId != nil:
+		price, opening.Priced = o.c.itemPrice(&opening, *d.CharmId)
+	}
+	if opening.Priced {
+		opening.Price = int64(math.Round(price * (1 - o.c.o.SellerFee)))
+	}
+	return opening
+}
+
+// itemPrice sets the catalog row of an opening and returns its price
+func (c *calculator) itemPrice(opening *Opening, id string) (float64, bool) {
+	opening.ItemID = id
+	price, ok := c.prices[id]
+	return float64(price), ok
+}
+
+// openSkin draws the float, StatTrak and phase of a skin, sets the item skin it makes and returns its price,
+// the price of a variant since the phases of a Doppler share the item of their market hash name
+func (o *opener) openSkin(opening *Opening, s *models.Skin) (float64, bool) {
+	souvenir := o.cs.Type == models.SouvenirPackage
+	if s.Stattrak && o.cs.Type == models.WeaponCase {
+		opening.StatTrak = o.rng.Float64() < StatTrakChance
+	}
+
+	f := s.MinFloat
+	if s.MaxFloat > s.MinFloat {
+		f += o.rng.Float64() * (s.MaxFloat - s.MinFloat)
+	}
+	opening.Float = &f
+
+	key := variantKey{skinID: s.ID, stattrak: opening.StatTrak, souvenir: souvenir}
+	// vanilla knives have no wear
+	if _, ok := o.c.variants[variantKey{skinID: s.ID, souvenir: souvenir}]; !ok {
+		if w, err := wear.ForFloat(f); err == nil {
+			opening.Wear = w
+			key.wear = w
+		}
+	}
+
+	ids := o.c.variants[key]
+	if len(ids) == 0 {
+		return 0, false
+	}
+	opening.ItemID = ids[o.rng.IntN(len(ids))]
+	return o.c.variantPrice(ids)
+}
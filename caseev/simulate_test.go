@@ -0,0 +1,138 @@
+package caseev
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+// fixture is a weapon case with a priced Mil-Spec skin, a Restricted skin priced in a single wear
+// and a vanilla knife, valued without a database
+func fixture() (*calculator, models.Case) {
+	milSpec, restricted := "rarity_rare_weapon", "rarity_mythical_weapon"
+	redline := &models.Skin{ID: "skin-1", Name: "AK-47 | Redline", MinFloat: 0.1, MaxFloat: 0.7, Stattrak: true}
+	asiimov := &models.Skin{ID: "skin-2", Name: "AWP | Asiimov", MinFloat: 0.18, MaxFloat: 1, Stattrak: true}
+	karambit := &models.Skin{ID: "skin-3", Name: "★ Karambit", Stattrak: true}
+
+	cs := models.Case{ID: "crate-1", Name: "Weapon Case", Type: models.WeaponCase, Drops: []models.CaseDrop{
+		{CaseID: "crate-1", ItemID: redline.ID, RarityId: &milSpec, SkinId: &redline.ID, Skin: redline},
+		{CaseID: "crate-1", ItemID: asiimov.ID, RarityId: &restricted, SkinId: &asiimov.ID, Skin: asiimov},
+		{CaseID: "crate-1", ItemID: karambit.ID, Rare: true, SkinId: &karambit.ID, Skin: karambit},
+	}}
+
+	c := &calculator{
+		o:        Options{Marketplace: "steam", KeyCost: 249, SellerFee: 0.13},
+		currency: "USD",
+		variants: make(map[variantKey][]string),
+		prices:   map[string]int64{cs.ID: 100, "skin-2_ft": 2500},
+	}
+	wears := []models.WearType{models.FactoryNew, models.MinimalWear, models.FieldTested, models.WellWorn, models.BattleScarred}
+	for i, w := range wears {
+		for _, stattrak := range []bool{false, true} {
+			id := redline.ID + "_" + string(w)
+			price := int64(300 - 50*i)
+			if stattrak {
+				id += "_st"
+				price *= 3
+			}
+			c.variants[variantKey{skinID: redline.ID, wear: w, stattrak: stattrak}] = []string{id}
+			c.prices[id] = price
+		}
+	}
+	c.variants[variantKey{skinID: asiimov.ID, wear: models.FieldTested}] = []string{"skin-2_ft"}
+	c.variants[variantKey{skinID: asiimov.ID, wear: models.BattleScarred}] = []string{"skin-2_bs"}
+	c.variants[variantKey{skinID: karambit.ID}] = []string{"skin-3"}
+	c.variants[variantKey{skinID: karambit.ID, stattrak: true}] = []string{"skin-3_st"}
+	c.prices["skin-3"] = 40000
+	return c, cs
+}
+
+func TestSimulateIsDeterministic(t *testing.T) {
+	c, cs := fixture()
+	s := SimulationOptions{Opens: 50, Trials: 200, Seed: 1}
+
+	first, err := c.simulate(cs, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.simulate(cs, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("two runs of seed %d differ: mean %v and %v", s.Seed, first.Mean, second.Mean)
+	}
+
+	if len(first.Profits) != s.Trials || !slices.IsSorted(first.Profits) {
+		t.Errorf("profits of %d trials, sorted %t", len(first.Profits), slices.IsSorted(first.Profits))
+	}
+	if first.Cost != 349 || first.Unpriced == 0 {
+		t.Errorf("cost %d, unpriced %d", first.Cost, first.Unpriced)
+	}
+
+	s.Seed = 2
+	other, err := c.simulate(cs, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Equal(first.Profits, other.Profits) {
+		t.Error("seeds 1 and 2 gave the same profits")
+	}
+}
+
+func TestSimulateUnpricedCase(t *testing.T) {
+	c, cs := fixture()
+	delete(c.prices, cs.ID)
+	if _, err := c.simulate(cs, SimulationOptions{Opens: 1, Trials: 1}); !errors.Is(err, ErrUnpricedCase) {
+		t.Errorf("simulate = %v, want %v", err, ErrUnpricedCase)
+	}
+
+	cs.Drops = nil
+	if _, err := c.simulate(cs, SimulationOptions{Opens: 1, Trials: 1}); !errors.Is(err, ErrNoDrops) {
+		t.Errorf("simulate without drops = %v, want %v", err, ErrNoDrops)
+	}
+}
+
+// the phases of a Doppler share the item of their market hash name, only one of them has a price
+func TestSimulatePricesDopplerPhases(t *testing.T) {
+	doppler := &models.Skin{ID: "skin-4", Name: "★ Karambit | Doppler", MinFloat: 0, MaxFloat: 0.07}
+	cs := models.Case{ID: "crate-2", Name: "Chroma Case", Type: models.WeaponCase, Drops: []models.CaseDrop{
+		{CaseID: "crate-2", ItemID: doppler.ID, Rare: true, SkinId: &doppler.ID, Skin: doppler},
+	}}
+	c := &calculator{
+		o:        Options{Marketplace: "steam", KeyCost: 249, SellerFee: 0.13},
+		currency: "USD",
+		variants: map[variantKey][]string{
+			{skinID: doppler.ID, wear: models.FactoryNew}: {"skin-4_phase1", "skin-4_phase2"},
+		},
+		prices: map[string]int64{cs.ID: 100, "skin-4_phase1": 50000},
+	}
+
+	o := c.opener(&cs, rand.New(rand.NewPCG(1, 1)))
+	drawn := make(map[string]bool)
+	for range 100 {
+		opening := o.open()
+		drawn[opening.ItemID] = true
+		if !opening.Priced || opening.Price != 43500 {
+			t.Fatalf("%s opened at %d, priced %t, want 43500", opening.ItemID, opening.Price, opening.Priced)
+		}
+	}
+	if !drawn["skin-4_phase1"] || !drawn["skin-4_phase2"] {
+		t.Errorf("drew the phases %v, want both", drawn)
+	}
+
+	sim, err := c.simulate(cs, SimulationOptions{Opens: 10, Trials: 20, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := float64(10 * (43500 - 349)); sim.Unpriced != 0 || sim.Mean != want || sim.BreakEven != 1 {
+		t.Errorf("mean %v, unpriced %d, break even %v, want %v, 0, 1", sim.Mean, sim.Unpriced, sim.BreakEven, want)
+	}
+	if r := c.result(cs); r.EV != 43500 || r.Coverage != 1 {
+		t.Errorf("Calculate has EV %v and coverage %v, want 43500 and 1", r.EV, r.Coverage)
+	}
+}
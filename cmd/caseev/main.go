@@ -0,0 +1,101 @@
+// Command caseev reports the expected value of opening every case, sorted by ROI, or simulates opening a case
+//
+//	go run ./cmd/caseev -marketplace steam -key 249 -fee 0.13
+//	go run ./cmd/caseev -case crate-4904 -simulate 100 -trials 10000 -seed 42
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"github.com/massimomarsiglia/cs-skins-market-models/caseev"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/prices"
+)
+
+func main() {
+	o := caseev.DefaultOptions
+	flag.StringVar(&o.Marketplace, "marketplace", o.Marketplace, "marketplace the prices are read from")
+	flag.Int64Var(&o.KeyCost, "key", o.KeyCost, "cost of a key in minor units of the currency of the marketplace")
+	flag.Float64Var(&o.SellerFee, "fee", o.SellerFee, "fraction of the sale price kept by the marketplace")
+	flag.DurationVar(&o.MaxAge, "max-age", o.MaxAge, "ignore prices observed earlier, 0 keeps every price")
+	caseID := flag.String("case", "", "report the drops of a single case")
+	var s caseev.SimulationOptions
+	flag.IntVar(&s.Opens, "simulate", 0, "simulate opening the -case this many times per trial instead")
+	flag.IntVar(&s.Trials, "trials", 1000, "number of simulated trials")
+	flag.Uint64Var(&s.Seed, "seed", 1, "seed of the simulation, the same seed gives the same results")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	database.Connect()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	if *caseID != "" && s.Opens > 0 {
+		sim, err := caseev.Simulate(*caseID, o, s, database.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(w, "%s\t%d trials of %d opens\tcost %s per open\tseed %d\t\n",
+			sim.Case.Name, s.Trials, s.Opens, money(float64(sim.Cost), sim.Currency), s.Seed)
+		fmt.Fprintf(w, "mean profit\t%s\t\n", money(sim.Mean, sim.Currency))
+		for _, p := range []float64{5, 25, 50, 75, 95} {
+			fmt.Fprintf(w, "P%.0f profit\t%s\t\n", p, money(sim.Percentile(p), sim.Currency))
+		}
+		fmt.Fprintf(w, "break even\t%s\t\n", percent(sim.BreakEven))
+		fmt.Fprintf(w, "unpriced openings\t%d\t\n", sim.Unpriced)
+		return
+	}
+
+	if *caseID != "" {
+		result, err := caseev.Calculate(*caseID, o, database.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(w, "%s\tcost %s\tEV %s\tROI %s\tcoverage %s\t\n",
+			result.Case.Name, price(result.Cost, result.Priced, result.Currency), money(result.EV, result.Currency), percent(result.ROI), percent(result.Coverage))
+		fmt.Fprintln(w, "Drop\tTier\tOdds\tValue\tCoverage\t")
+		for _, d := range result.Drops {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", d.Drop.Name, d.Tier, percent(d.Odds), money(d.Value, result.Currency), percent(d.Coverage))
+		}
+		return
+	}
+
+	results, err := caseev.CalculateAll(o, database.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(w, "Case\tType\tCost\tEV\tROI\tCoverage\t")
+	for _, r := range results {
+		roi := "-"
+		if r.Priced {
+			roi = percent(r.ROI)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			r.Case.Name, r.Case.Type, price(r.Cost, r.Priced, r.Currency), money(r.EV, r.Currency), roi, percent(r.Coverage))
+	}
+}
+
+func money(minor float64, currency string) string {
+	digits := prices.MinorDigits(currency)
+	return fmt.Sprintf("%.*f %s", digits, minor/math.Pow10(digits), currency)
+}
+
+func price(minor int64, priced bool, currency string) string {
+	if !priced {
+		return "-"
+	}
+	return money(float64(minor), currency)
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%.2f%%", f*100)
+}
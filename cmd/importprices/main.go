@@ -0,0 +1,158 @@
+// Command importprices imports offline price dumps of the marketplaces into the price observations
+//
+//	go run ./cmd/importprices -format skinport -rejects rejects.csv items-2026-10-18.json
+//	go run ./cmd/importprices -format steam -currency EUR overviews-2026-10-18.json
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/prices"
+	"gorm.io/gorm"
+)
+
+func main() {
+	format := flag.String("format", "", "format of the dumps, one of "+strings.Join(prices.FormatNames(), ", "))
+	at := flag.String("at", "", "RFC 3339 time of the entries without one, defaults to the time in the name of each dump, ex. items-2026-10-18T14-00.json")
+	rejects := flag.String("rejects", "", "csv file the rejected entries of every dump are written to, they are logged when empty")
+	currency := flag.String("currency", "", "ISO 4217 currency of the dumps, required by formats that don't tell it such as steam")
+	flag.Parse()
+
+	f, err := prices.LookupFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cf, ok := f.(prices.CurrencyFormat); ok {
+		if len(*currency) != 3 {
+			log.Fatalf("%s dumps need -currency, the 3 letter code of their currency", *format)
+		}
+		f = cf.WithCurrency(*currency)
+	} else if *currency != "" {
+		log.Fatalf("%s dumps tell their currency, -currency doesn't apply", *format)
+	}
+	if flag.NArg() == 0 {
+		log.Fatal("No dump to import")
+	}
+
+	var observedAt time.Time
+	if *at != "" {
+		if observedAt, err = time.Parse(time.RFC3339, *at); err != nil {
+			log.Fatalf("Invalid -at %q: %v", *at, err)
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	database.InitDB()
+
+	r := prices.NewRepository()
+	rejected := make(map[string][]prices.Reject)
+	for _, path := range flag.Args() {
+		result, err := importDump(r, f, path, observedAt)
+		if err != nil {
+			log.Fatalf("Error importing %s: %v", path, err)
+		}
+		fmt.Printf("Imported %s: %d of %d entries recorded on %s, %d rejected\n",
+			path, result.Recorded, result.Entries, result.Marketplace, len(result.Rejects))
+		rejected[path] = result.Rejects
+	}
+
+	if *rejects == "" {
+		for _, path := range flag.Args() {
+			for _, reject := range rejected[path] {
+				log.Printf("Rejected %s row %d %q: %s", path, reject.Row, reject.MarketHashName, reject.Reason)
+			}
+		}
+		return
+	}
+	if err := writeRejects(*rejects, flag.Args(), rejected); err != nil {
+		log.Fatalf("Error writing the reject report: %v", err)
+	}
+}
+
+// writeRejects writes the reject report as csv, one row per rejected entry in the order of the dumps
+func writeRejects(path string, dumps []string, rejected map[string][]prices.Reject) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"dump", "row", "market_hash_name", "reason"}); err != nil {
+		return err
+	}
+	for _, dump := range dumps {
+		for _, reject := range rejected[dump] {
+			if err := w.Write([]string{dump, strconv.Itoa(reject.Row), reject.MarketHashName, reject.Reason}); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// importDump imports a dump and rolls its candles up in a transaction, the time in the name of the dump is used
+// when observedAt is zero so importing the same file again overwrites its observations
+func importDump(r *prices.Repository, f prices.Format, path string, observedAt time.Time) (prices.ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return prices.ImportResult{}, err
+	}
+	defer file.Close()
+
+	if observedAt.IsZero() {
+		observedAt, _ = nameTime(path)
+	}
+
+	// the candles of the imported range are rolled up again in the same transaction
+	var result prices.ImportResult
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if result, err = r.Import(f, file, observedAt, tx); err != nil || result.Recorded == 0 {
+			return err
+		}
+		_, err = r.RollupRange(result.From, result.To, tx)
+		return err
+	})
+	if errors.Is(err, prices.ErrNoObservedAt) {
+		return result, fmt.Errorf("%w, with -at or in the name of the dump", err)
+	}
+	return result, err
+}
+
+// layouts of the times in the names of the dumps, colons are left out since they aren't portable in file names
+var nameTimeLayouts = []string{"2006-01-02T15-04-05", "2006-01-02T15-04", "2006-01-02"}
+
+var nameTimePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}(T\d{2}-\d{2}(-\d{2})?)?`)
+
+// nameTime returns the time in UTC in the base name of a dump, the last one when there are several
+func nameTime(path string) (time.Time, bool) {
+	matches := nameTimePattern.FindAllString(filepath.Base(path), -1)
+	if len(matches) == 0 {
+		return time.Time{}, false
+	}
+	match := matches[len(matches)-1]
+	for _, layout := range nameTimeLayouts {
+		if t, err := time.Parse(layout, match); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNameTime(t *testing.T) {
+	tests := []struct {
+		path string
+		want time.Time
+		ok   bool
+	}{
+		{"dumps/items-2026-10-18.json", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), true},
+		{"overviews-2026-10-18T14-30.json", time.Date(2026, 10, 18, 14, 30, 0, 0, time.UTC), true},
+		{"buff_2026-10-18T14-30-15.csv", time.Date(2026, 10, 18, 14, 30, 15, 0, time.UTC), true},
+		{"2026-01-01/items-2026-10-18.json", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), true},
+		{"items.json", time.Time{}, false},
+		{"items-2026-13-40.json", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := nameTime(tt.path)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("nameTime(%q) = %v, %t, want %v, %t", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
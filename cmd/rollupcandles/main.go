@@ -0,0 +1,64 @@
+// Command rollupcandles rolls the price observations up into candles, incrementally since the last candles
+// or over a range to recompute it
+//
+//	go run ./cmd/rollupcandles
+//	go run ./cmd/rollupcandles -from 2026-01-01T00:00:00Z -to 2026-02-01T00:00:00Z
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/prices"
+	"gorm.io/gorm"
+)
+
+func main() {
+	from := flag.String("from", "", "RFC 3339 start of the range to recompute")
+	to := flag.String("to", "", "RFC 3339 end of the range to recompute, defaults to now")
+	lookback := flag.Duration("lookback", prices.DefaultLookback, "how far before the last candles late observations are rolled up, without -from")
+	flag.Parse()
+
+	end := time.Now()
+	if *to != "" {
+		t, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			log.Fatalf("Invalid -to %q: %v", *to, err)
+		}
+		end = t
+	}
+	var start time.Time
+	if *from != "" {
+		t, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			log.Fatalf("Invalid -from %q: %v", *from, err)
+		}
+		start = t
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	database.InitDB()
+
+	t := time.Now()
+	r := prices.NewRepository()
+	r.Lookback = *lookback
+	var written int64
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if start.IsZero() {
+			written, err = r.Rollup(end, tx)
+		} else {
+			written, err = r.RollupRange(start, end, tx)
+		}
+		return err
+	}); err != nil {
+		log.Fatalf("Error rolling up candles: %v", err)
+	}
+	fmt.Printf("Rolled up %d candles in %s\n", written, time.Since(t))
+}
@@ -40,11 +40,16 @@ func InitDB() {
 		&models.Collection{},
 		&models.Wear{},
 		&models.Skin{},
+		&models.SkinPhase{},
+		&models.CollectionSkin{},
+		&models.PatternTier{},
+		&models.Player{},
 		&models.Sticker{},
 		&models.Patch{},
 		&models.Agent{},
 		&models.Charm{},
 		&models.Case{},
+		&models.CaseDrop{},
 		&models.Item{},
 		&models.ItemProperties{},
 		&models.StickerAttributes{},
@@ -52,10 +57,20 @@ func InitDB() {
 		&models.CharmAttributes{},
 		&models.ItemAttributes{},
 		&models.ItemSkin{},
+		&models.PopulateRun{},
+		&models.Retirement{},
+		&models.RunPayload{},
+		&models.Translation{},
+		&models.Marketplace{},
+		&models.PriceCandle{},
 	); err != nil {
 		log.Fatalf("Failed to migrate item tables: %v", err)
 	}
 
+	if err := createPriceTables(); err != nil {
+		log.Fatalf("Failed to migrate price tables: %v", err)
+	}
+
 	log.Println("Database migration completed successfully")
 }
 
@@ -92,3 +107,29 @@ func createEnums() {
         $$;
     `)
 }
+
+// price observations are partitioned by day since millions of them are written per day,
+// gorm can't declare partitions so the table is created here and partitions by prices.Repository
+func createPriceTables() error {
+	if err := DB.Exec(`
+        CREATE TABLE IF NOT EXISTS price_observations (
+            item_id        text        NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+            marketplace_id text        NOT NULL REFERENCES marketplaces (id) ON DELETE CASCADE,
+            observed_at    timestamptz NOT NULL,
+            currency       char(3)     NOT NULL,
+            lowest_ask     bigint,
+            highest_bid    bigint,
+            median_sale    bigint,
+            volume         integer,
+            PRIMARY KEY (item_id, marketplace_id, observed_at)
+        ) PARTITION BY RANGE (observed_at)
+    `).Error; err != nil {
+		return err
+	}
+
+	// the primary key serves the time ranges of an item, this one the snapshots of a whole marketplace
+	return DB.Exec(`
+        CREATE INDEX IF NOT EXISTS idx_price_observations_marketplace
+            ON price_observations (marketplace_id, observed_at)
+    `).Error
+}
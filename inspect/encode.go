@@ -0,0 +1,63 @@
+package inspect
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+)
+
+// Encode returns the CEconItemPreviewDataBlock message of the preview
+func (p *Preview) Encode() []byte {
+	var e encoder
+	e.varint(1, uint64(p.AccountID))
+	e.varint(2, p.ItemID)
+	e.varint(3, uint64(p.DefIndex))
+	e.varint(4, uint64(p.PaintIndex))
+	e.varint(5, uint64(p.Rarity))
+	e.varint(6, uint64(p.Quality))
+	e.varint(7, uint64(math.Float32bits(p.PaintWear)))
+	e.varint(8, uint64(p.PaintSeed))
+	e.optional(9, p.KillEaterScoreType)
+	e.optional(10, p.KillEaterValue)
+	e.bytes(11, []byte(p.CustomName))
+	for _, s := range p.Stickers {
+		e.bytes(12, s.encode())
+	}
+	e.varint(13, uint64(p.Inventory))
+	e.varint(14, uint64(p.Origin))
+	e.varint(15, uint64(p.QuestID))
+	e.varint(16, uint64(p.DropReason))
+	e.varint(17, uint64(p.MusicIndex))
+	e.varint(18, uint64(uint32(p.EntIndex)))
+	e.varint(19, uint64(p.PetIndex))
+	for _, k := range p.Keychains {
+		e.bytes(20, k.encode())
+	}
+	return e.b
+}
+
+func (s *Sticker) encode() []byte {
+	var e encoder
+	e.varint(1, uint64(s.Slot))
+	e.varint(2, uint64(s.StickerID))
+	e.float(3, s.Wear)
+	e.float(4, s.Scale)
+	e.float(5, s.Rotation)
+	e.varint(6, uint64(s.TintID))
+	e.float(7, s.OffsetX)
+	e.float(8, s.OffsetY)
+	e.float(9, s.OffsetZ)
+	e.varint(10, uint64(s.Pattern))
+	return e.b
+}
+
+// Link returns the masked inspect link of the preview, unmasked since a key byte of 0 is valid too
+func (p *Preview) Link() string {
+	message := p.Encode()
+	b := make([]byte, 0, len(message)+5)
+	b = append(b, 0)
+	b = append(b, message...)
+	b = binary.BigEndian.AppendUint32(b, checksum(b, len(message)))
+	return LinkPrefix + strings.ToUpper(hex.EncodeToString(b))
+}
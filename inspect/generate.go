@@ -0,0 +1,163 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// QualityNormal is the quality of regular weapon skins
+const QualityNormal = 4
+
+// def indexes of the gloves, they are generated with !gengl and can't have stickers
+var gloveDefIndexes = map[uint32]struct{}{
+	4725: {}, 5027: {}, 5030: {}, 5031: {}, 5032: {}, 5033: {}, 5034: {}, 5035: {},
+}
+
+// stickerSlots is the number of sticker slots of a gen code
+const stickerSlots = 5
+
+// PreviewOf builds the preview of stored attributes of a weapon skin, their stickers, patches and charms must be loaded
+func PreviewOf(a *models.ItemAttributes, tx *gorm.DB) (*Preview, error) {
+	var item struct {
+		DefIndex   uint32
+		PaintIndex uint32
+		Name       string
+		Stattrak   bool
+		Souvenir   bool
+	}
+	result := tx.Table("item_skins AS i").
+		Select("w.def_index, COALESCE(p.paint_index, s.paint_index) AS paint_index, s.name, i.stattrak, i.souvenir").
+		Joins("JOIN skins s ON s.id = i.skin_id").
+		Joins("JOIN weapons w ON w.id = s.weapon_id").
+		Joins("LEFT JOIN skin_phases p ON p.skin_id = s.id AND p.phase = i.phase").
+		Where("i.id = ?", a.ItemID).
+		Scan(&item)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s is not a stored item skin", ErrUnknownItem, a.ItemID)
+	}
+	if item.DefIndex == 0 {
+		return nil, fmt.Errorf("%w: the weapon of %s has no def index", ErrUnknownItem, item.Name)
+	}
+
+	p := &Preview{
+		DefIndex:   item.DefIndex,
+		PaintIndex: item.PaintIndex,
+		Quality:    QualityNormal,
+	}
+	switch {
+	case strings.HasPrefix(item.Name, "★ "):
+		p.Quality = QualityUnusual
+	case item.Souvenir:
+		p.Quality = QualitySouvenir
+	case item.Stattrak:
+		p.Quality = QualityStatTrak
+	}
+	if item.Stattrak {
+		var kills uint32
+		p.KillEaterScoreType = &kills
+		p.KillEaterValue = &kills
+	}
+	if err := p.apply(a); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// apply sets the float, paint seed, stickers, patches and charms of the attributes on the preview
+func (p *Preview) apply(a *models.ItemAttributes) error {
+	if a.Float != nil {
+		p.PaintWear = float32(*a.Float)
+	}
+	if a.PaintSeed != nil {
+		p.PaintSeed = uint32(*a.PaintSeed)
+	}
+
+	for _, s := range a.Stickers {
+		id, err := definition(s.StickerID, "sticker-")
+		if err != nil {
+			return err
+		}
+		slot, err := gameSlot(s.Slot)
+		if err != nil {
+			return err
+		}
+		p.Stickers = append(p.Stickers, Sticker{Slot: slot, StickerID: id, Wear: float32(s.Perc / 100)})
+	}
+	for _, patch := range a.Patches {
+		id, err := definition(patch.PatchID, "patch-")
+		if err != nil {
+			return err
+		}
+		slot, err := gameSlot(patch.Slot)
+		if err != nil {
+			return err
+		}
+		p.Stickers = append(p.Stickers, Sticker{Slot: slot, StickerID: id})
+	}
+	for _, c := range a.Charms {
+		id, err := definition(c.CharmID, "keychain-")
+		if err != nil {
+			return err
+		}
+		slot, err := gameSlot(c.Slot)
+		if err != nil {
+			return err
+		}
+		p.Keychains = append(p.Keychains, Sticker{Slot: slot, StickerID: id, Pattern: uint32(c.PatternId)})
+	}
+	return nil
+}
+
+// definition returns the game definition of an api id, ex. 4547 of sticker-4547
+func definition(id, prefix string) (uint32, error) {
+	n, ok := strings.CutPrefix(id, prefix)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s is not a %s id", ErrUnknownSticker, id, strings.TrimSuffix(prefix, "-"))
+	}
+	v, err := strconv.ParseUint(n, 10, 32)
+	if err != nil {
+		return 0, errors.Join(ErrUnknownSticker, err)
+	}
+	return uint32(v), nil
+}
+
+// GenCode returns the console command generating the item, ex. "!gen 7 282 661 0.0123 4547 0.2 0 0 0 0 0 0 0 0"
+// gloves use !gengl and have no sticker slots, charms can't be generated this way
+func (p *Preview) GenCode() string {
+	command := "!gen"
+	_, gloves := gloveDefIndexes[p.DefIndex]
+	if gloves {
+		command = "!gengl"
+	}
+
+	args := []string{
+		command,
+		strconv.FormatUint(uint64(p.DefIndex), 10),
+		strconv.FormatUint(uint64(p.PaintIndex), 10),
+		strconv.FormatUint(uint64(p.PaintSeed), 10),
+		strconv.FormatFloat(float64(p.PaintWear), 'f', -1, 32),
+	}
+	if !gloves {
+		var slots [stickerSlots]Sticker
+		for _, s := range p.Stickers {
+			if s.Slot < stickerSlots {
+				slots[s.Slot] = s
+			}
+		}
+		for _, s := range slots {
+			args = append(args,
+				strconv.FormatUint(uint64(s.StickerID), 10),
+				strconv.FormatFloat(float64(s.Wear), 'f', -1, 32),
+			)
+		}
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,68 @@
+package inspect
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+func TestSlotRoundTrip(t *testing.T) {
+	float := 0.0123
+	seed := uint16(661)
+	a := &models.ItemAttributes{
+		Float:     &float,
+		PaintSeed: &seed,
+		Stickers: []models.StickerAttributes{
+			{Slot: 1, StickerID: "sticker-4547", Perc: 20},
+			{Slot: 3, StickerID: "sticker-5012"},
+			{Slot: 5, StickerID: "sticker-76"},
+		},
+		Patches: []models.PatchAttributes{{Slot: 4, PatchID: "patch-4550"}},
+		Charms:  []models.CharmAttributes{{Slot: 1, CharmID: "keychain-1", PatternId: 42}},
+	}
+
+	p := &Preview{DefIndex: 7, PaintIndex: 282, Quality: QualityNormal}
+	if err := p.apply(a); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Parse(p.Link())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l.Preview, p) {
+		t.Fatalf("decoded %+v, encoded %+v", l.Preview, p)
+	}
+
+	// the decoded slots are those of the game, stored back they must match the attributes
+	stored := make(map[string]uint8)
+	for _, s := range l.Preview.Stickers {
+		stored[stickerID(s.StickerID)] = modelSlot(s.Slot)
+		stored[patchID(s.StickerID)] = modelSlot(s.Slot)
+	}
+	for _, s := range a.Stickers {
+		if stored[s.StickerID] != s.Slot {
+			t.Errorf("%s came back in slot %d, stored in %d", s.StickerID, stored[s.StickerID], s.Slot)
+		}
+	}
+	if got := stored[a.Patches[0].PatchID]; got != a.Patches[0].Slot {
+		t.Errorf("patch came back in slot %d, stored in %d", got, a.Patches[0].Slot)
+	}
+	if k := l.Preview.Keychains; len(k) != 1 || modelSlot(k[0].Slot) != 1 || k[0].Pattern != 42 {
+		t.Errorf("charms = %+v", k)
+	}
+
+	want := "!gen 7 282 661 0.0123 4547 0.2 0 0 5012 0 4550 0 76 0"
+	if got := p.GenCode(); got != want {
+		t.Errorf("GenCode() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyRejectsUnsetSlot(t *testing.T) {
+	a := &models.ItemAttributes{Stickers: []models.StickerAttributes{{StickerID: "sticker-4547"}}}
+	if err := new(Preview).apply(a); !errors.Is(err, ErrInvalidSlot) {
+		t.Errorf("apply = %v, want %v", err, ErrInvalidSlot)
+	}
+}
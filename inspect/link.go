@@ -0,0 +1,270 @@
+// Package inspect decodes the inspect links of items
+// classic links only identify the item (S/M, A and D), masked links carry its full preview data
+package inspect
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LinkPrefix is how the game opens an inspect link, the payload follows it
+const LinkPrefix = "steam://rungame/730/76561202255233023/+csgo_econ_action_preview "
+
+var (
+	ErrInvalidLink = errors.New("invalid inspect link")
+	ErrChecksum    = errors.New("inspect link checksum mismatch")
+)
+
+var classicPayload = regexp.MustCompile(`^([SM])(\d+)A(\d+)D(\d+)$`)
+
+// Link is a decoded inspect link
+type Link struct {
+	// classic links, Owner is the steam id of an inventory item and Market the listing id of a market item
+	Owner  uint64
+	Market uint64
+	Asset  uint64
+	D      uint64
+
+	// masked links, nil for classic ones which need the game coordinator to be looked up
+	Preview *Preview
+}
+
+// Classic reports whether the link only identifies the item
+func (l *Link) Classic() bool {
+	return l.Preview == nil
+}
+
+// Preview is the CEconItemPreviewDataBlock message of the game
+type Preview struct {
+	AccountID          uint32
+	ItemID             uint64
+	DefIndex           uint32
+	PaintIndex         uint32
+	Rarity             uint32
+	Quality            uint32
+	PaintWear          float32 // float of the item, stored as the bits of a float32
+	PaintSeed          uint32
+	KillEaterScoreType *uint32
+	KillEaterValue     *uint32 // set on StatTrak items, even with 0 kills
+	CustomName         string
+	Stickers           []Sticker
+	Inventory          uint32
+	Origin             uint32
+	QuestID            uint32
+	DropReason         uint32
+	MusicIndex         uint32
+	EntIndex           int32
+	PetIndex           uint32
+	Keychains          []Sticker // charms, their pattern is kept in Sticker.Pattern
+}
+
+// Sticker is a sticker, patch or charm applied to the item
+type Sticker struct {
+	Slot      uint32
+	StickerID uint32 // sticker kit, patch or charm definition
+	Wear      float32
+	Scale     float32
+	Rotation  float32
+	TintID    uint32
+	OffsetX   float32
+	OffsetY   float32
+	OffsetZ   float32
+	Pattern   uint32
+}
+
+// Qualities of the game that matter for the variant of an item
+const (
+	QualityUnusual  = 3 // ★ items, ex. knives and gloves
+	QualityStatTrak = 9
+	QualitySouvenir = 12
+)
+
+// StatTrak reports whether the item counts kills
+func (p *Preview) StatTrak() bool {
+	return p.KillEaterValue != nil || p.Quality == QualityStatTrak
+}
+
+// Souvenir reports whether the item dropped from a souvenir package
+func (p *Preview) Souvenir() bool {
+	return p.Quality == QualitySouvenir
+}
+
+// Float returns the float of the item as the shortest decimal its float32 stands for, ex. 0.06 rather than
+// the 0.0599999986 of a plain conversion, which would fall out of a skin whose min float is 0.06
+func (p *Preview) Float() float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(p.PaintWear), 'g', -1, 32), 64)
+	return f
+}
+
+// Parse decodes an inspect link, either the full steam:// url, possibly url encoded, or its payload
+func Parse(link string) (Link, error) {
+	payload := strings.TrimSpace(link)
+	if unescaped, err := url.PathUnescape(payload); err == nil {
+		payload = unescaped
+	}
+	if i := strings.Index(payload, "csgo_econ_action_preview"); i >= 0 {
+		payload = strings.TrimSpace(payload[i+len("csgo_econ_action_preview"):])
+	}
+
+	if m := classicPayload.FindStringSubmatch(payload); m != nil {
+		var l Link
+		id, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			return Link{}, fmt.Errorf("%w: %v", ErrInvalidLink, err)
+		}
+		if m[1] == "S" {
+			l.Owner = id
+		} else {
+			l.Market = id
+		}
+		if l.Asset, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+			return Link{}, fmt.Errorf("%w: %v", ErrInvalidLink, err)
+		}
+		if l.D, err = strconv.ParseUint(m[4], 10, 64); err != nil {
+			return Link{}, fmt.Errorf("%w: %v", ErrInvalidLink, err)
+		}
+		return l, nil
+	}
+
+	b, err := hex.DecodeString(payload)
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: payload is neither S/M A D nor hex", ErrInvalidLink)
+	}
+	p, err := decodeMasked(b)
+	if err != nil {
+		return Link{}, err
+	}
+	return Link{Asset: p.ItemID, Preview: p}, nil
+}
+
+// decodeMasked decodes the payload of a masked link: a key byte, the message and a checksum,
+// every byte xor'ed with the key when it isn't 0
+func decodeMasked(b []byte) (*Preview, error) {
+	if len(b) < 5 {
+		return nil, fmt.Errorf("%w: payload too short", ErrInvalidLink)
+	}
+	if key := b[0]; key != 0 {
+		for i := range b {
+			b[i] ^= key
+		}
+	}
+
+	message := b[1 : len(b)-4]
+	if binary.BigEndian.Uint32(b[len(b)-4:]) != checksum(b[:len(b)-4], len(message)) {
+		return nil, ErrChecksum
+	}
+
+	p, err := decodePreview(message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLink, err)
+	}
+	return p, nil
+}
+
+// checksum of a masked payload, b is the unmasked key byte followed by the message
+func checksum(b []byte, messageLen int) uint32 {
+	crc := crc32.ChecksumIEEE(b)
+	return (crc & 0xffff) ^ (uint32(messageLen) * crc)
+}
+
+func decodePreview(b []byte) (*Preview, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Preview{}
+	for _, f := range fs {
+		v := uint32(f.varint)
+		switch f.number {
+		case 1:
+			p.AccountID = v
+		case 2:
+			p.ItemID = f.varint
+		case 3:
+			p.DefIndex = v
+		case 4:
+			p.PaintIndex = v
+		case 5:
+			p.Rarity = v
+		case 6:
+			p.Quality = v
+		case 7:
+			p.PaintWear = f.float()
+		case 8:
+			p.PaintSeed = v
+		case 9:
+			p.KillEaterScoreType = &v
+		case 10:
+			p.KillEaterValue = &v
+		case 11:
+			p.CustomName = string(f.bytes)
+		case 12, 20:
+			s, err := decodeSticker(f.bytes)
+			if err != nil {
+				return nil, err
+			}
+			if f.number == 12 {
+				p.Stickers = append(p.Stickers, s)
+			} else {
+				p.Keychains = append(p.Keychains, s)
+			}
+		case 13:
+			p.Inventory = v
+		case 14:
+			p.Origin = v
+		case 15:
+			p.QuestID = v
+		case 16:
+			p.DropReason = v
+		case 17:
+			p.MusicIndex = v
+		case 18:
+			p.EntIndex = int32(v)
+		case 19:
+			p.PetIndex = v
+		}
+	}
+	return p, nil
+}
+
+func decodeSticker(b []byte) (Sticker, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return Sticker{}, err
+	}
+
+	var s Sticker
+	for _, f := range fs {
+		switch f.number {
+		case 1:
+			s.Slot = uint32(f.varint)
+		case 2:
+			s.StickerID = uint32(f.varint)
+		case 3:
+			s.Wear = f.float()
+		case 4:
+			s.Scale = f.float()
+		case 5:
+			s.Rotation = f.float()
+		case 6:
+			s.TintID = uint32(f.varint)
+		case 7:
+			s.OffsetX = f.float()
+		case 8:
+			s.OffsetY = f.float()
+		case 9:
+			s.OffsetZ = f.float()
+		case 10:
+			s.Pattern = uint32(f.varint)
+		}
+	}
+	return s, nil
+}
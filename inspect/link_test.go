@@ -0,0 +1,116 @@
+package inspect
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/wear"
+)
+
+func TestParseClassic(t *testing.T) {
+	tests := []struct {
+		link string
+		want Link
+	}{
+		{
+			"steam://rungame/730/76561202255233023/+csgo_econ_action_preview%20S76561198084749846A698323590D7935523998312483177",
+			Link{Owner: 76561198084749846, Asset: 698323590, D: 7935523998312483177},
+		},
+		{
+			"steam://rungame/730/76561202255233023/+csgo_econ_action_preview M625254122282020305A6760346663D30614827701953021",
+			Link{Market: 625254122282020305, Asset: 6760346663, D: 30614827701953021},
+		},
+		{"S76561198084749846A698323590D7935523998312483177", Link{Owner: 76561198084749846, Asset: 698323590, D: 7935523998312483177}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.link)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.link, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || !got.Classic() {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.link, got, tt.want)
+		}
+	}
+}
+
+// the link of a StatTrak AK-47 | Case Hardened masked with the key 0xA7, the message and checksum were
+// written by hand following the game's format rather than with Encode
+const maskedLink = "steam://rungame/730/76561202255233023/+csgo_econ_action_preview%20" +
+	"A7B7057119592EA6BFA0878B8FA297AE9F2822704CA4E732A2EFA7F71EADC5ADAFA7B76484BAA7A72799C5A2AFA4B73380D7AF05A6A0AFA7B783F73586F5C1A8B2"
+
+// the same payload with a key byte of 0, which leaves it unmasked
+const unmaskedPayload = "0010A2D6BEFE89011807202C28053009388F85D7EB03409505480050B90A620A080010C3231D0000803E620508031094277008A201070800102450922152660F15"
+
+func TestParseMasked(t *testing.T) {
+	scoreType, kills := uint32(0), uint32(1337)
+	want := &Preview{
+		ItemID:             37040925474,
+		DefIndex:           7,
+		PaintIndex:         44,
+		Rarity:             5,
+		Quality:            QualityStatTrak,
+		PaintWear:          0.06,
+		PaintSeed:          661,
+		KillEaterScoreType: &scoreType,
+		KillEaterValue:     &kills,
+		Stickers:           []Sticker{{Slot: 0, StickerID: 4547, Wear: 0.25}, {Slot: 3, StickerID: 5012}},
+		Origin:             8,
+		Keychains:          []Sticker{{Slot: 0, StickerID: 36, Pattern: 4242}},
+	}
+
+	for _, link := range []string{maskedLink, unmaskedPayload} {
+		l, err := Parse(link)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", link, err)
+		}
+		if l.Classic() || l.Asset != want.ItemID {
+			t.Fatalf("Parse(%q) = %+v", link, l)
+		}
+		if !reflect.DeepEqual(l.Preview, want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", link, l.Preview, want)
+		}
+		if l.Preview.Float() != 0.06 || !l.Preview.StatTrak() {
+			t.Errorf("float %v, StatTrak %t", l.Preview.Float(), l.Preview.StatTrak())
+		}
+	}
+}
+
+func TestParseCorrupt(t *testing.T) {
+	payload := []byte(maskedLink[len(maskedLink)-len(unmaskedPayload):])
+	badChecksum := string(payload[:len(payload)-1]) + "0"
+	badKey := "A6" + string(payload[2:])
+
+	tests := []struct {
+		link string
+		err  error
+	}{
+		{badChecksum, ErrChecksum},
+		{badKey, ErrChecksum},
+		{"A7B70571", ErrInvalidLink},
+		{"S76561198084749846A698323590", ErrInvalidLink},
+		{"not an inspect link", ErrInvalidLink},
+	}
+	for _, tt := range tests {
+		if l, err := Parse(tt.link); !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q) = %+v, %v, want %v", tt.link, l, err, tt.err)
+		}
+	}
+}
+
+func TestPreviewFloat(t *testing.T) {
+	// float32(0.06) widens to 0.0599999986, below the min float of the skin
+	p := &Preview{PaintWear: 0.06}
+	skin := &models.Skin{Name: "AK-47 | Case Hardened", MinFloat: 0.06, MaxFloat: 0.8}
+	if w, err := wear.Validate(skin, p.Float()); err != nil || w != models.FactoryNew {
+		t.Errorf("Validate(%v) = %q, %v", p.Float(), w, err)
+	}
+
+	// float32(0.38) widens to 0.3799999952, which is still Field-Tested
+	p = &Preview{PaintWear: 0.38}
+	if w, err := wear.ForFloat(p.Float()); err != nil || w != models.WellWorn {
+		t.Errorf("ForFloat(%v) = %q, %v", p.Float(), w, err)
+	}
+}
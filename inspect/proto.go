@@ -0,0 +1,115 @@
+package inspect
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// minimal protobuf wire format, enough for CEconItemPreviewDataBlock of the game
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+type field struct {
+	number int
+	wire   int
+	varint uint64 // value of varint and fixed fields
+	bytes  []byte // value of length delimited fields
+}
+
+// fields splits a message in its fields, in the order they appear
+func fields(b []byte) ([]field, error) {
+	var fs []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+
+		f := field{number: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			f.varint, b = v, b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			f.varint, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			f.varint, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errTruncated
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return nil, errors.New("unsupported protobuf wire type")
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// float32 of a fixed32 field
+func (f field) float() float32 {
+	return math.Float32frombits(uint32(f.varint))
+}
+
+// encoder appends fields to a message, zero values are left out like the game does
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) key(number, wire int) {
+	e.b = binary.AppendUvarint(e.b, uint64(number)<<3|uint64(wire))
+}
+
+func (e *encoder) varint(number int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.key(number, wireVarint)
+	e.b = binary.AppendUvarint(e.b, v)
+}
+
+// optional writes a varint field even when it is 0, nil leaves it out
+func (e *encoder) optional(number int, v *uint32) {
+	if v == nil {
+		return
+	}
+	e.key(number, wireVarint)
+	e.b = binary.AppendUvarint(e.b, uint64(*v))
+}
+
+func (e *encoder) float(number int, v float32) {
+	if v == 0 {
+		return
+	}
+	e.key(number, wireFixed32)
+	e.b = binary.LittleEndian.AppendUint32(e.b, math.Float32bits(v))
+}
+
+func (e *encoder) bytes(number int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	e.key(number, wireBytes)
+	e.b = binary.AppendUvarint(e.b, uint64(len(v)))
+	e.b = append(e.b, v...)
+}
@@ -0,0 +1,188 @@
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/wear"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNeedsGameCoordinator = errors.New("classic inspect links don't carry the item data")
+	ErrUnknownItem          = errors.New("no stored item skin matches the inspect link")
+	ErrUnknownSticker       = errors.New("no stored sticker, patch or charm matches the inspect link")
+	ErrInvalidSlot          = errors.New("invalid sticker slot")
+)
+
+// ids of the api are the game definitions with a prefix, ex. sticker-4547
+func stickerID(id uint32) string { return fmt.Sprintf("sticker-%d", id) }
+func patchID(id uint32) string   { return fmt.Sprintf("patch-%d", id) }
+func charmID(id uint32) string   { return fmt.Sprintf("keychain-%d", id) }
+
+// Attributes resolves a masked link of a weapon skin to the stored catalog and returns its attributes with
+// the stickers, patches and charms applied, ready to be created with their children
+// the attributes id is the asset id when the link has one, generated links don't so it is derived from the item
+func Attributes(l Link, tx *gorm.DB) (models.ItemAttributes, error) {
+	if l.Classic() {
+		return models.ItemAttributes{}, ErrNeedsGameCoordinator
+	}
+	p := l.Preview
+
+	skin, itemID, err := resolveItemSkin(p, tx)
+	if err != nil {
+		return models.ItemAttributes{}, err
+	}
+
+	id := attributesID(p)
+	attributes := models.ItemAttributes{ID: id, ItemID: itemID}
+	if p.PaintIndex != 0 {
+		f := p.Float()
+		if _, err := wear.Validate(&skin, f); err != nil {
+			return models.ItemAttributes{}, err
+		}
+		attributes.Float = &f
+		seed := uint16(p.PaintSeed)
+		attributes.PaintSeed = &seed
+	}
+
+	for _, s := range p.Stickers {
+		// agents carry patches in the sticker slots
+		isSticker, err := exists(&models.Sticker{}, stickerID(s.StickerID), tx)
+		if err != nil {
+			return models.ItemAttributes{}, err
+		}
+		isPatch, err := exists(&models.Patch{}, patchID(s.StickerID), tx)
+		if err != nil {
+			return models.ItemAttributes{}, err
+		}
+
+		switch {
+		case isSticker:
+			attributes.Stickers = append(attributes.Stickers, models.StickerAttributes{
+				ID:           fmt.Sprintf("%s-%d", id, s.Slot),
+				AttributesID: id,
+				Slot:         modelSlot(s.Slot),
+				StickerID:    stickerID(s.StickerID),
+				Perc:         float64(s.Wear) * 100,
+			})
+		case isPatch:
+			attributes.Patches = append(attributes.Patches, models.PatchAttributes{
+				ID:           fmt.Sprintf("%s-%d", id, s.Slot),
+				AttributesID: id,
+				Slot:         modelSlot(s.Slot),
+				PatchID:      patchID(s.StickerID),
+			})
+		default:
+			return models.ItemAttributes{}, fmt.Errorf("%w: %d in slot %d", ErrUnknownSticker, s.StickerID, s.Slot)
+		}
+	}
+
+	for _, k := range p.Keychains {
+		isCharm, err := exists(&models.Charm{}, charmID(k.StickerID), tx)
+		if err != nil {
+			return models.ItemAttributes{}, err
+		}
+		if !isCharm {
+			return models.ItemAttributes{}, fmt.Errorf("%w: charm %d in slot %d", ErrUnknownSticker, k.StickerID, k.Slot)
+		}
+		attributes.Charms = append(attributes.Charms, models.CharmAttributes{
+			ID:           fmt.Sprintf("%s-%d", id, k.Slot),
+			AttributesID: id,
+			Slot:         modelSlot(k.Slot),
+			CharmID:      charmID(k.StickerID),
+			PatternId:    uint16(k.Pattern),
+		})
+	}
+	return attributes, nil
+}
+
+// resolveItemSkin finds the item skin of the weapon, paint, wear and StatTrak/Souvenir variant
+func resolveItemSkin(p *Preview, tx *gorm.DB) (models.Skin, string, error) {
+	// the phases of the Doppler family share a skin, each with its own paint index
+	var phase models.SkinPhase
+	err := tx.Preload("Skin").
+		Joins("JOIN skins s ON s.id = skin_phases.skin_id").
+		Joins("JOIN weapons w ON w.id = s.weapon_id").
+		Where("w.def_index = ? AND skin_phases.paint_index = ?", p.DefIndex, p.PaintIndex).
+		First(&phase).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Skin{}, "", err
+	}
+
+	skin := phase.Skin
+	if err != nil {
+		if err := tx.Joins("JOIN weapons w ON w.id = skins.weapon_id").
+			Where("w.def_index = ? AND skins.paint_index = ?", p.DefIndex, p.PaintIndex).
+			First(&skin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.Skin{}, "", fmt.Errorf("%w: def index %d, paint index %d", ErrUnknownItem, p.DefIndex, p.PaintIndex)
+			}
+			return models.Skin{}, "", err
+		}
+	}
+
+	query := tx.Model(&models.ItemSkin{}).
+		Where("item_skins.skin_id = ? AND item_skins.stattrak = ? AND item_skins.souvenir = ?", skin.ID, p.StatTrak(), p.Souvenir())
+	if phase.Phase != "" {
+		query = query.Where("item_skins.phase = ?", phase.Phase)
+	}
+	if p.PaintIndex == 0 {
+		// vanilla items have no wear
+		query = query.Where("item_skins.wear_id IS NULL")
+	} else {
+		w, err := wear.ForFloat(p.Float())
+		if err != nil {
+			return models.Skin{}, "", err
+		}
+		query = query.Joins("JOIN wears ON wears.id = item_skins.wear_id").Where("wears.name = ?", w)
+	}
+
+	var ids []string
+	if err := query.Limit(1).Pluck("item_skins.id", &ids).Error; err != nil {
+		return models.Skin{}, "", err
+	}
+	if len(ids) == 0 {
+		return models.Skin{}, "", fmt.Errorf("%w: no %s variant of %s", ErrUnknownItem, variant(p), skin.Name)
+	}
+	return skin, ids[0], nil
+}
+
+// modelSlot converts a slot of the game, counted from 0, to the slot stored in the attributes, counted from 1
+func modelSlot(slot uint32) uint8 { return uint8(slot + 1) }
+
+// gameSlot converts a stored slot back to the one of the game
+func gameSlot(slot uint8) (uint32, error) {
+	if slot == 0 {
+		return 0, fmt.Errorf("%w: slots are counted from 1", ErrInvalidSlot)
+	}
+	return uint32(slot - 1), nil
+}
+
+func variant(p *Preview) string {
+	switch {
+	case p.StatTrak():
+		return fmt.Sprintf("StatTrak %v", p.PaintWear)
+	case p.Souvenir():
+		return fmt.Sprintf("Souvenir %v", p.PaintWear)
+	default:
+		return fmt.Sprint(p.PaintWear)
+	}
+}
+
+func exists(model any, id string, tx *gorm.DB) (bool, error) {
+	var count int64
+	if err := tx.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func attributesID(p *Preview) string {
+	if p.ItemID != 0 {
+		return fmt.Sprint(p.ItemID)
+	}
+	return fmt.Sprintf("%d-%d-%d-%08x", p.DefIndex, p.PaintIndex, p.PaintSeed, math.Float32bits(p.PaintWear))
+}
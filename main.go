@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI"
+	"github.com/massimomarsiglia/cs-skins-market-models/CSGOAPI/client"
 	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/patterntier"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -14,8 +22,62 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 	database.InitDB()
-	pop := CSGOAPI.NewPopulator()
-	pop.PopulateDB()
+
+	var opts []CSGOAPI.Option
+	if size := os.Getenv("BATCH_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			log.Fatalf("Invalid BATCH_SIZE %q: %v", size, err)
+		}
+		opts = append(opts, CSGOAPI.WithBatchSize(n))
+	}
+
+	if locales := os.Getenv("LOCALES"); locales != "" {
+		opts = append(opts, CSGOAPI.WithLocales(strings.Split(locales, ",")...))
+	}
+
+	if os.Getenv("FORCE") != "" {
+		opts = append(opts, CSGOAPI.WithForce())
+	}
+
+	var clientOpts []client.ClientOption
+	if dir := os.Getenv("CSGOAPI_CACHE_DIR"); dir != "" {
+		clientOpts = append(clientOpts, client.WithCacheDir(dir))
+	}
+
+	source, err := client.NewSource(os.Getenv("CSGOAPI_SOURCE"), clientOpts...)
+	if err != nil {
+		log.Fatalf("Invalid CSGOAPI_SOURCE: %v", err)
+	}
+	opts = append(opts, CSGOAPI.WithSource(source))
+
+	// stop fetching when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pop := CSGOAPI.NewPopulator(opts...)
+	pop.PopulateDB(ctx)
+
+	if path := os.Getenv("PATTERN_TIERS"); path != "" {
+		importPatternTiers(path)
+	}
 }
 
+func importPatternTiers(path string) {
+	records, err := patterntier.Load(path)
+	if err != nil {
+		log.Fatalf("Invalid PATTERN_TIERS %q: %v", path, err)
+	}
 
+	var result patterntier.ImportResult
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result, err = patterntier.Import(records, tx)
+		return err
+	}); err != nil {
+		log.Fatalf("Error importing pattern tiers: %v", err)
+	}
+	for _, skin := range result.Unknown {
+		log.Printf("Pattern tiers of unknown skin %s skipped", skin)
+	}
+	log.Printf("Imported %d pattern tiers from %s", result.Imported, path)
+}
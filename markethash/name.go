@@ -0,0 +1,161 @@
+// Package markethash parses and formats the market hash names of skins,
+// ex. "★ StatTrak™ Karambit | Doppler (Factory New)", and resolves them to the stored item skins
+package markethash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+const (
+	StatTrakPrefix = "StatTrak™ "
+	SouvenirPrefix = "Souvenir "
+	StarPrefix     = "★ " // knives and gloves
+
+	// PhaseSeparator precedes the phase of the Doppler family, the steam market doesn't have it but other markets do,
+	// ex. "★ Karambit | Doppler (Factory New) - Phase 2"
+	PhaseSeparator = " - "
+)
+
+// Wears in the order of their float ranges
+var Wears = []models.WearType{
+	models.FactoryNew,
+	models.MinimalWear,
+	models.FieldTested,
+	models.WellWorn,
+	models.BattleScarred,
+}
+
+var ErrInvalidName = errors.New("invalid market hash name")
+
+// Name is a market hash name split in its components
+type Name struct {
+	StatTrak bool
+	Souvenir bool
+	Star     bool
+	Weapon   string              // ex. "Karambit" or "AWP"
+	Finish   string              // ex. "Doppler", empty for vanilla knives
+	Wear     models.WearType     // empty for items without wears such as vanilla knives
+	Phase    models.DopplerPhase // empty for steam names and skins out of the Doppler family
+}
+
+// Parse splits a market hash name, the prefixes are accepted in any order and a known phase may follow the wear
+// only a known wear in the trailing parentheses is taken as the wear, finishes like "龍王 (Dragon King)" keep theirs
+func Parse(s string) (Name, error) {
+	var n Name
+	// only spaces are trimmed, String joins the parts with spaces and other whitespace would not survive it
+	rest := strings.Trim(s, " ")
+
+	for prefixed := true; prefixed; {
+		switch {
+		case strings.HasPrefix(rest, StatTrakPrefix) && !n.StatTrak:
+			n.StatTrak = true
+			rest = strings.TrimPrefix(rest, StatTrakPrefix)
+		case strings.HasPrefix(rest, SouvenirPrefix) && !n.Souvenir:
+			n.Souvenir = true
+			rest = strings.TrimPrefix(rest, SouvenirPrefix)
+		case strings.HasPrefix(rest, StarPrefix) && !n.Star:
+			n.Star = true
+			rest = strings.TrimPrefix(rest, StarPrefix)
+		default:
+			prefixed = false
+		}
+	}
+
+	if n.StatTrak && n.Souvenir {
+		return Name{}, fmt.Errorf("%w: %q is both StatTrak and Souvenir", ErrInvalidName, s)
+	}
+
+	if i := strings.LastIndex(rest, PhaseSeparator); i >= 0 {
+		if phase, ok := parsePhase(rest[i+len(PhaseSeparator):]); ok {
+			n.Phase = phase
+			rest = rest[:i]
+		}
+	}
+
+	if open := strings.LastIndex(rest, " ("); open >= 0 && strings.HasSuffix(rest, ")") {
+		if wear, ok := parseWear(rest[open+2 : len(rest)-1]); ok {
+			n.Wear = wear
+			rest = rest[:open]
+		}
+	}
+
+	weapon, finish, _ := strings.Cut(rest, " | ")
+	n.Weapon = strings.Trim(weapon, " ")
+	n.Finish = strings.Trim(finish, " ")
+	if n.Weapon == "" {
+		return Name{}, fmt.Errorf("%w: %q has no weapon", ErrInvalidName, s)
+	}
+	// a part starting like a prefix is a mangled name, ex. "StatTrak™\r | Redline", String would make it a prefix
+	for _, part := range []string{n.Weapon, n.Finish} {
+		if hasPrefixToken(part) {
+			return Name{}, fmt.Errorf("%w: %q has a misplaced prefix", ErrInvalidName, s)
+		}
+	}
+	return n, nil
+}
+
+// hasPrefixToken reports whether s starts with one of the prefixes, without the space that follows it
+func hasPrefixToken(s string) bool {
+	for _, prefix := range []string{StatTrakPrefix, SouvenirPrefix, StarPrefix} {
+		if strings.HasPrefix(s, strings.TrimSuffix(prefix, " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWear(s string) (models.WearType, bool) {
+	for _, wear := range Wears {
+		if string(wear) == s {
+			return wear, true
+		}
+	}
+	return "", false
+}
+
+func parsePhase(s string) (models.DopplerPhase, bool) {
+	for _, phase := range models.DopplerPhases {
+		if strings.EqualFold(string(phase), strings.TrimSpace(s)) {
+			return phase, true
+		}
+	}
+	return "", false
+}
+
+// String formats the name the way the steam market does, followed by the phase if there is one, ex. "★ StatTrak™ Karambit | Doppler (Factory New)"
+func (n Name) String() string {
+	var b strings.Builder
+	if n.Star {
+		b.WriteString(StarPrefix)
+	}
+	if n.StatTrak {
+		b.WriteString(StatTrakPrefix)
+	}
+	if n.Souvenir {
+		b.WriteString(SouvenirPrefix)
+	}
+	b.WriteString(n.Weapon)
+	if n.Finish != "" {
+		b.WriteString(" | ")
+		b.WriteString(n.Finish)
+	}
+	if n.Wear != "" {
+		b.WriteString(" (")
+		b.WriteString(string(n.Wear))
+		b.WriteString(")")
+	}
+	if n.Phase != "" {
+		b.WriteString(PhaseSeparator)
+		b.WriteString(string(n.Phase))
+	}
+	return b.String()
+}
+
+// SkinName is the name of the skin the item is a variant of, as stored in the skins table
+func (n Name) SkinName() string {
+	return Name{Star: n.Star, Weapon: n.Weapon, Finish: n.Finish}.String()
+}
@@ -0,0 +1,83 @@
+package markethash
+
+import (
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Name
+	}{
+		{"AK-47 | Redline (Field-Tested)", Name{Weapon: "AK-47", Finish: "Redline", Wear: models.FieldTested}},
+		{"StatTrak™ AWP | Asiimov (Battle-Scarred)", Name{StatTrak: true, Weapon: "AWP", Finish: "Asiimov", Wear: models.BattleScarred}},
+		{"Souvenir M4A1-S | Knight (Factory New)", Name{Souvenir: true, Weapon: "M4A1-S", Finish: "Knight", Wear: models.FactoryNew}},
+		{"★ StatTrak™ Karambit | Doppler (Factory New)", Name{Star: true, StatTrak: true, Weapon: "Karambit", Finish: "Doppler", Wear: models.FactoryNew}},
+		{"★ Karambit | Doppler (Factory New) - Phase 2", Name{Star: true, Weapon: "Karambit", Finish: "Doppler", Wear: models.FactoryNew, Phase: models.Phase2}},
+		{"★ Butterfly Knife", Name{Star: true, Weapon: "Butterfly Knife"}},
+		{"M4A4 | 龍王 (Dragon King) (Minimal Wear)", Name{Weapon: "M4A4", Finish: "龍王 (Dragon King)", Wear: models.MinimalWear}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+		if got.String() != tt.s {
+			t.Errorf("String() = %q, want %q", got.String(), tt.s)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"   ",
+		"StatTrak™ Souvenir AK-47 | Redline (Field-Tested)",
+		"StatTrak™\r | 0",
+		"StatTrak™ StatTrak™ AK-47 | Redline",
+		"AK-47 | ★Redline",
+	} {
+		if n, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", s, n)
+		}
+	}
+}
+
+// FuzzParse checks that every name Parse accepts survives a round trip through String
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"AK-47 | Redline (Field-Tested)",
+		"StatTrak™ AWP | Asiimov (Battle-Scarred)",
+		"Souvenir M4A1-S | Knight (Factory New)",
+		"★ StatTrak™ Karambit | Doppler (Factory New)",
+		"★ Karambit | Gamma Doppler (Minimal Wear) - Emerald",
+		"★ Butterfly Knife",
+		"★ Sport Gloves | Pandora's Box (Well-Worn)",
+		"M4A4 | 龍王 (Dragon King) (Minimal Wear)",
+		"Sticker | s1mple (Gold) | Stockholm 2021",
+		"StatTrak™\r | 0",
+		"0 |\f | 0",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		x, err := Parse(s)
+		if err != nil {
+			return
+		}
+		got, err := Parse(x.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) = %+v, its String %q doesn't parse: %v", s, x, x.String(), err)
+		}
+		if got != x {
+			t.Fatalf("Parse(String(%+v)) = %+v, from %q", x, got, s)
+		}
+	})
+}
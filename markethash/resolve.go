@@ -0,0 +1,169 @@
+package markethash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownWeapon  = errors.New("unknown weapon")
+	ErrUnknownFinish  = errors.New("unknown finish")
+	ErrUnknownVariant = errors.New("unknown variant") // the skin exists but not with this wear, StatTrak or Souvenir
+)
+
+// Unknown is a name that could not be resolved
+type Unknown struct {
+	Name string
+	Err  error
+}
+
+func (u Unknown) String() string {
+	return fmt.Sprintf("%s: %v", u.Name, u.Err)
+}
+
+type variantKey struct {
+	skinID   string
+	wear     models.WearType
+	stattrak bool
+	souvenir bool
+}
+
+// Resolver resolves market hash names to item skin ids against a snapshot of the weapons, skins and item skins tables
+type Resolver struct {
+	weapons  map[string]struct{}
+	skins    map[[2]string][]string // weapon and finish to skin ids, several when skins share a name
+	variants map[variantKey][]variant
+}
+
+type variant struct {
+	id    string
+	phase models.DopplerPhase
+}
+
+// NewResolver loads the tables, retired rows included since their names still appear in price histories
+func NewResolver(tx *gorm.DB) (*Resolver, error) {
+	r := &Resolver{
+		weapons:  make(map[string]struct{}),
+		skins:    make(map[[2]string][]string),
+		variants: make(map[variantKey][]variant),
+	}
+
+	var weapons []string
+	if err := tx.Model(&models.Weapon{}).Pluck("name", &weapons).Error; err != nil {
+		return nil, err
+	}
+	for _, weapon := range weapons {
+		r.weapons[weapon] = struct{}{}
+	}
+
+	var skins []struct {
+		ID     string
+		Name   string
+		Weapon string
+	}
+	if err := tx.Table("skins AS s").
+		Select("s.id, s.name, w.name AS weapon").
+		Joins("JOIN weapons w ON w.id = s.weapon_id").
+		Scan(&skins).Error; err != nil {
+		return nil, err
+	}
+	for _, skin := range skins {
+		// skin names are market hash names without prefixes other than the star and without wear
+		name, err := Parse(skin.Name)
+		if err != nil {
+			continue
+		}
+		key := [2]string{skin.Weapon, name.Finish}
+		r.skins[key] = append(r.skins[key], skin.ID)
+	}
+
+	var items []struct {
+		ID       string
+		SkinID   string
+		Wear     *string
+		Stattrak bool
+		Souvenir bool
+		Phase    *string
+	}
+	if err := tx.Table("item_skins AS i").
+		Select("i.id, i.skin_id, w.name::text AS wear, i.stattrak, i.souvenir, i.phase").
+		Joins("LEFT JOIN wears w ON w.id = i.wear_id").
+		Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		key := variantKey{skinID: item.SkinID, stattrak: item.Stattrak, souvenir: item.Souvenir}
+		if item.Wear != nil {
+			key.wear = models.WearType(*item.Wear)
+		}
+		v := variant{id: item.ID}
+		if item.Phase != nil {
+			v.phase = models.DopplerPhase(*item.Phase)
+		}
+		r.variants[key] = append(r.variants[key], v)
+	}
+	return r, nil
+}
+
+// Resolve returns the ids of the item skins with the market hash name
+// a name matches several ids when items only differ by something the name doesn't tell, ex. a Doppler without its phase
+func (r *Resolver) Resolve(s string) ([]string, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return r.ResolveName(n)
+}
+
+// ResolveName is Resolve for a parsed name
+func (r *Resolver) ResolveName(n Name) ([]string, error) {
+	if _, ok := r.weapons[n.Weapon]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWeapon, n.Weapon)
+	}
+
+	skinIDs, ok := r.skins[[2]string{n.Weapon, n.Finish}]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFinish, n.SkinName())
+	}
+
+	var ids []string
+	for _, skinID := range skinIDs {
+		for _, v := range r.variants[variantKey{
+			skinID:   skinID,
+			wear:     n.Wear,
+			stattrak: n.StatTrak,
+			souvenir: n.Souvenir,
+		}] {
+			if n.Phase == "" || n.Phase == v.phase {
+				ids = append(ids, v.id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVariant, n)
+	}
+	return ids, nil
+}
+
+// ResolveAll resolves every name, names that can't be resolved are reported instead
+func (r *Resolver) ResolveAll(names []string) (map[string][]string, []Unknown) {
+	resolved := make(map[string][]string, len(names))
+	var unknown []Unknown
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if _, ok := resolved[name]; ok {
+			continue
+		}
+		ids, err := r.Resolve(name)
+		if err != nil {
+			unknown = append(unknown, Unknown{Name: name, Err: err})
+			continue
+		}
+		resolved[name] = ids
+	}
+	return resolved, unknown
+}
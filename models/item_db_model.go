@@ -13,15 +13,20 @@ const (
 type Tournament struct {
 	ID    uint32           `gorm:"primaryKey"`
 	Name  string           `gorm:"unique;not null"`
-	Teams []TournamentTeam `gorm:"many2many:tournament_team_relations;"`
+	Teams []TournamentTeam `gorm:"many2many:tournament_team_relations;"` // teams that had stickers at the tournament
+
+	Lifecycle
 }
 
 type TournamentTeam struct {
-	ID    uint32           `gorm:"primaryKey"`
-	Team  string           `gorm:"not null"`
-	Teams []TournamentTeam `gorm:"many2many:tournament_team_relations;"`
+	ID          uint32       `gorm:"primaryKey"`
+	Team        string       `gorm:"uniqueIndex;not null"`
+	Tournaments []Tournament `gorm:"many2many:tournament_team_relations;"` // tournaments the team had stickers at
+
+	Lifecycle
 }
 
+// TournamentTeamRelation is the participation of a team in a tournament, derived from the tournament stickers
 type TournamentTeamRelation struct {
 	TournamentID     uint32 `gorm:"primaryKey"`
 	TournamentTeamID uint32 `gorm:"primaryKey"`
@@ -30,17 +35,43 @@ type TournamentTeamRelation struct {
 type Wear struct {
 	ID   string   `gorm:"primaryKey"`
 	Name WearType `gorm:"type:wear_type;not null"`
+
+	Lifecycle
 }
 
 type Rarity struct {
 	ID    string `gorm:"primaryKey"`
 	Name  string `gorm:"not null"`
 	Color string
+
+	Lifecycle
 }
 
+type WeaponClass string
+
+const (
+	PistolClass WeaponClass = "Pistol"
+	SMGClass    WeaponClass = "SMG"
+	RifleClass  WeaponClass = "Rifle"
+	SniperClass WeaponClass = "Sniper Rifle"
+	HeavyClass  WeaponClass = "Heavy"
+	KnifeClass  WeaponClass = "Knife"
+	GlovesClass WeaponClass = "Gloves"
+)
+
 type Weapon struct {
-	ID   string `gorm:"primaryKey"`
-	Name string `gorm:"not null"`
+	ID       string      `gorm:"primaryKey"`
+	Name     string      `gorm:"not null"`
+	DefIndex uint16      `gorm:"index"` // item definition index of the game, used by inspect links
+	Class    WeaponClass // empty until the weapon is synced from the skins endpoint
+
+	// sides the weapon is available to
+	Terrorist        bool
+	CounterTerrorist bool
+
+	Special bool // ★ items, knives and gloves
+
+	Lifecycle
 }
 
 type Collection struct {
@@ -48,17 +79,47 @@ type Collection struct {
 	Name     string    `gorm:"unique;not null"`
 	Image    string    `gorm:"not null"`
 	Crates   []Case    `gorm:"foreignKey:CollectionId"`
-	Skins    []Skin    `gorm:"foreignKey:CollectionId"`
 	Stickers []Sticker `gorm:"foreignKey:CollectionId"`
-	Agents   []Agent   `gorm:"foreignKey:CollectionId"`
-	Charms   []Charm   `gorm:"foreignKey:CollectionId"`
+
+	// every member of the collection, not only the items having it as their primary collection
+	Skins  []Skin  `gorm:"many2many:skin_collections;"`
+	Agents []Agent `gorm:"many2many:agent_collections;"`
+	Charms []Charm `gorm:"many2many:charm_collections;"`
+
+	// skins as listed by the collections endpoint, Skins is the view of the skins endpoint
+	Contents []CollectionSkin `gorm:"foreignKey:CollectionID;references:ID;constraint:OnDelete:CASCADE"`
+
+	Lifecycle
 }
 
+// CollectionSkin is a skin listed in the contents of a collection
+type CollectionSkin struct {
+	CollectionID string  `gorm:"primaryKey"`
+	ItemID       string  `gorm:"primaryKey"` // upstream id of the listed skin
+	Name         string  `gorm:"not null"`
+	PaintIndex   uint16  `gorm:"not null"`
+	SkinId       *string `gorm:"default:null;index"` // stored skin the entry resolves to
+	Skin         *Skin   `gorm:"foreignKey:SkinId;references:ID;constraint:OnDelete:SET NULL"`
+}
+
+type ItemType string
+
+// values of the item_type enum
+const (
+	CharmItem   ItemType = "Charm"
+	SkinItem    ItemType = "Skin"
+	StickerItem ItemType = "Sticker"
+	PatchItem   ItemType = "Patch"
+	AgentItem   ItemType = "Agent"
+	CaseItem    ItemType = "Case"
+)
+
 // Item instance
+// the registry has one item per market hash name, its id is the id of the entity it resolves to
 type Item struct {
-	ID             string `gorm:"primaryKey"`
-	MarketHashName string `gorm:"unique;not null"`
-	Type           string `gorm:"type:item_type"`
+	ID             string   `gorm:"primaryKey"`
+	MarketHashName string   `gorm:"unique;not null"`
+	Type           ItemType `gorm:"type:item_type"`
 
 	Props      *ItemProperties `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE"`
 	Attributes *ItemAttributes `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE"`
@@ -83,22 +144,44 @@ type ItemProperties struct {
 	Sticker  *Sticker  `gorm:"foreignKey:StickerId;references:ID;constraint:OnDelete:CASCADE"`
 }
 
+// Entity returns the loaded entity the properties point at, nil if it wasn't preloaded
+func (p *ItemProperties) Entity() any {
+	switch {
+	case p.SkinItem != nil:
+		return p.SkinItem
+	case p.Sticker != nil:
+		return p.Sticker
+	case p.Patch != nil:
+		return p.Patch
+	case p.Agent != nil:
+		return p.Agent
+	case p.Charm != nil:
+		return p.Charm
+	case p.Case != nil:
+		return p.Case
+	default:
+		return nil
+	}
+}
+
 type ItemAttributes struct {
 	ID     string `gorm:"primaryKey"`
 	ItemID string `gorm:"not null"`
 	Item   Item   `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE"`
 
 	// Specific Item Attributes
-	Float    *float64
-	Stickers []StickerAttributes `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
-	Patches  []PatchAttributes   `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
-	Charms   []CharmAttributes   `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
+	Float     *float64
+	PaintSeed *uint16             // pattern of the paint, 0 to 1000
+	Stickers  []StickerAttributes `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
+	Patches   []PatchAttributes   `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
+	Charms    []CharmAttributes   `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 // Attributes for Patch on Item
 type PatchAttributes struct {
 	ID           string `gorm:"primaryKey"`
 	AttributesID string `gorm:"primaryKey"`
+	Slot         uint8  // 1-5, like StickerAttributes
 	PatchID      string
 
 	Attributes ItemAttributes `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
@@ -109,6 +192,7 @@ type PatchAttributes struct {
 type CharmAttributes struct {
 	ID           string `gorm:"primaryKey"`
 	AttributesID string `gorm:"primaryKey"`
+	Slot         uint8  // 1, items have a single charm slot
 	CharmID      string
 	PatternId    uint16
 
@@ -120,7 +204,7 @@ type CharmAttributes struct {
 type StickerAttributes struct {
 	ID           string `gorm:"primaryKey"` //individual ID incase slot is not unique
 	AttributesID string `gorm:"primaryKey"`
-	Slot         uint8  //1-5
+	Slot         uint8  //1-5, the game counts the slots from 0
 	StickerID    string
 
 	Attributes ItemAttributes `gorm:"foreignKey:AttributesID;references:ID;constraint:OnDelete:CASCADE"`
@@ -132,6 +216,7 @@ type StickerAttributes struct {
 // Specific ItemSkin (meaning the actual item)
 type ItemSkin struct {
 	ID             string `gorm:"primaryKey"`
+	Name           string // english name, translated in translations
 	MarketHashName string
 	Image          string `gorm:"not null"`
 	Stattrak       bool   `gorm:"not null"`                                                    // Defines if a skin is stattrak
@@ -141,21 +226,40 @@ type ItemSkin struct {
 
 	WearId *string `gorm:"default:null"`                                                // Foreign key reference
 	Wear   *Wear   `gorm:"foreignKey:WearId;references:ID;constraint:OnDelete:CASCADE"` // Ensures correct mapping to Wear.ID
+
+	Phase *DopplerPhase `gorm:"default:null"` // set for the Doppler family, see SkinPhase
+
+	Lifecycle
 }
 
 type Category struct {
 	ID   string `gorm:"primaryKey"`
 	Name string `gorm:"unique;not null"`
+
+	Lifecycle
 }
 
 type Team struct {
 	ID   string `gorm:"primaryKey"`
 	Name string `gorm:"unique;not null"`
+
+	Lifecycle
 }
 
 type Pattern struct {
 	ID   string `gorm:"primaryKey"`
 	Name string `gorm:"not null"`
+
+	Lifecycle
+}
+
+// PatternTier names a paint seed of a skin, ex. a blue gem of a Case Hardened or a 100% Fade
+// the tiers are curated, see the patterntier package to import them
+type PatternTier struct {
+	SkinId    string `gorm:"primaryKey"`
+	Skin      Skin   `gorm:"foreignKey:SkinId;references:ID;constraint:OnDelete:CASCADE"`
+	PaintSeed uint16 `gorm:"primaryKey"`
+	Tier      string `gorm:"not null"`
 }
 
 // define base skin without specific wears
@@ -173,8 +277,9 @@ type Skin struct {
 	Stattrak   bool    //defines if a skin can be stattrak
 	Souvenir   bool    //defines if a skin can be souvenir
 
-	CollectionId *string
-	Collection   *Collection `gorm:"foreignKey:CollectionId"`
+	CollectionId *string      // primary collection, the first one listed by the api
+	Collection   *Collection  `gorm:"foreignKey:CollectionId"`
+	Collections  []Collection `gorm:"many2many:skin_collections;"`
 
 	CategoryId string   `gorm:"not null"`
 	Category   Category `gorm:"foreignKey:CategoryId;references:ID;constraint:OnDelete:CASCADE"`
@@ -188,6 +293,38 @@ type Skin struct {
 	PatternId string  `gorm:"not null"`
 
 	Crates []Case `gorm:"many2many:skin_crates;"`
+
+	Phases []SkinPhase `gorm:"foreignKey:SkinId"` // empty unless the skin is of the Doppler family
+
+	Lifecycle
+}
+
+type DopplerPhase string
+
+// phases of the Doppler family, they share a skin but each one is painted with its own paint index
+const (
+	Phase1     DopplerPhase = "Phase 1"
+	Phase2     DopplerPhase = "Phase 2"
+	Phase3     DopplerPhase = "Phase 3"
+	Phase4     DopplerPhase = "Phase 4"
+	Ruby       DopplerPhase = "Ruby"
+	Sapphire   DopplerPhase = "Sapphire"
+	BlackPearl DopplerPhase = "Black Pearl"
+	Emerald    DopplerPhase = "Emerald" // Gamma Doppler only
+)
+
+var DopplerPhases = []DopplerPhase{Phase1, Phase2, Phase3, Phase4, Ruby, Sapphire, BlackPearl, Emerald}
+
+// SkinPhase is a phase of a Doppler family skin, skins.paint_index holds the paint index of one of them
+type SkinPhase struct {
+	ID         string       `gorm:"primaryKey"` // skin id and phase, ex. skin-...-phase-2
+	SkinId     string       `gorm:"not null;uniqueIndex:idx_skin_phase"`
+	Skin       Skin         `gorm:"foreignKey:SkinId;references:ID;constraint:OnDelete:CASCADE"`
+	Phase      DopplerPhase `gorm:"not null;uniqueIndex:idx_skin_phase"`
+	PaintIndex uint16       `gorm:"not null;index"`
+	Image      string       `gorm:"not null"`
+
+	Lifecycle
 }
 
 type SkinWear struct {
@@ -195,15 +332,45 @@ type SkinWear struct {
 	WearID string `gorm:"primaryKey"`
 }
 
+type SkinCollection struct {
+	SkinID       string `gorm:"primaryKey"`
+	CollectionID string `gorm:"primaryKey"`
+}
+
+// a skin drops from several crates, ex. souvenir packages of different majors
 type SkinCrate struct {
 	SkinID string `gorm:"primaryKey"`
-	CaseID string `gorm:"not null"`
+	CaseID string `gorm:"primaryKey"`
 }
 
+type StickerKind string
+
+const (
+	EventSticker     StickerKind = "event"     // logo of a tournament organizer
+	TeamSticker      StickerKind = "team"      // logo of a team at a tournament
+	AutographSticker StickerKind = "autograph" // signature of a player at a tournament
+	RegularSticker   StickerKind = "regular"
+)
+
+type StickerEffect string
+
+// finish effects of stickers, stickers without one (paper) have an empty effect
+const (
+	HoloEffect       StickerEffect = "Holo"
+	FoilEffect       StickerEffect = "Foil"
+	GoldEffect       StickerEffect = "Gold"
+	GlitterEffect    StickerEffect = "Glitter"
+	LenticularEffect StickerEffect = "Lenticular"
+)
+
 type Sticker struct {
-	ID    string `gorm:"primaryKey"` //id from game files
-	Name  string `gorm:"not null"`
-	Image string `gorm:"not null"`
+	ID             string `gorm:"primaryKey"` //id from game files
+	Name           string `gorm:"not null"`
+	MarketHashName string
+	Image          string `gorm:"not null"`
+
+	Kind   StickerKind   `gorm:"not null;default:regular;index"`
+	Effect StickerEffect `gorm:"index"`
 
 	RarityId string `gorm:"not null"`
 	Rarity   Rarity `gorm:"foreignKey:RarityId"`
@@ -219,14 +386,37 @@ type Sticker struct {
 	TeamId       *uint32         //optional
 	Tournament   *Tournament     `gorm:"foreignKey:TournamentId"`
 	Team         *TournamentTeam `gorm:"foreignKey:TeamId"`
+
+	//Autograph stickers
+	PlayerId *uint32 //optional
+	Player   *Player `gorm:"foreignKey:PlayerId"`
+
+	Lifecycle
+}
+
+// Player is a pro player with autograph stickers
+type Player struct {
+	ID    uint32           `gorm:"primaryKey"`
+	Name  string           `gorm:"unique;not null"`
+	Teams []TournamentTeam `gorm:"many2many:player_teams;"` // teams the player signed stickers for
+
+	Lifecycle
+}
+
+type PlayerTeam struct {
+	PlayerID         uint32 `gorm:"primaryKey"`
+	TournamentTeamID uint32 `gorm:"primaryKey"`
 }
 
 type Patch struct {
-	ID       string `gorm:"primaryKey"`
-	Name     string `gorm:"unique;not null"`
-	RarityId string `gorm:"not null"`
-	Rarity   Rarity `gorm:"foreignKey:RarityId"`
-	Image    string `gorm:"not null"`
+	ID             string `gorm:"primaryKey"`
+	Name           string `gorm:"unique;not null"`
+	MarketHashName string
+	RarityId       string `gorm:"not null"`
+	Rarity         Rarity `gorm:"foreignKey:RarityId"`
+	Image          string `gorm:"not null"`
+
+	Lifecycle
 }
 
 type TeamType string
@@ -237,35 +427,88 @@ const (
 )
 
 type Agent struct {
-	ID           string     `gorm:"primaryKey"`
-	Name         string     `gorm:"unique;not null"`
-	CollectionId string     `gorm:"not null"`
-	Collection   Collection `gorm:"foreignKey:CollectionId"`
-	RarityId     string     `gorm:"not null"`
-	Rarity       Rarity     `gorm:"foreignKey:RarityId"`
-	Image        string     `gorm:"not null"`
+	ID             string `gorm:"primaryKey"`
+	Name           string `gorm:"unique;not null"`
+	MarketHashName string
+	CollectionId   *string      `gorm:"default:null"` // primary collection, the first one listed by the api
+	Collection     *Collection  `gorm:"foreignKey:CollectionId"`
+	Collections    []Collection `gorm:"many2many:agent_collections;"`
+	RarityId       string       `gorm:"not null"`
+	Rarity         Rarity       `gorm:"foreignKey:RarityId"`
+	Image          string       `gorm:"not null"`
 
 	TeamId string `gorm:"not null"`
 	Team   Team   `gorm:"foreignKey:TeamId"`
+
+	Lifecycle
+}
+
+type AgentCollection struct {
+	AgentID      string `gorm:"primaryKey"`
+	CollectionID string `gorm:"primaryKey"`
 }
 
 type Charm struct {
-	ID           string     `gorm:"primaryKey"`
-	Name         string     `gorm:"unique;not null"`
-	CollectionId string     `gorm:"not null"`
-	Collection   Collection `gorm:"foreignKey:CollectionId"`
-	RarityId     string     `gorm:"not null"`
-	Rarity       Rarity     `gorm:"foreignKey:RarityId"`
-	Image        string     `gorm:"not null"`
+	ID             string `gorm:"primaryKey"`
+	Name           string `gorm:"unique;not null"`
+	MarketHashName string
+	CollectionId   *string      `gorm:"default:null"` // primary collection, the first one listed by the api
+	Collection     *Collection  `gorm:"foreignKey:CollectionId"`
+	Collections    []Collection `gorm:"many2many:charm_collections;"`
+	RarityId       string       `gorm:"not null"`
+	Rarity         Rarity       `gorm:"foreignKey:RarityId"`
+	Image          string       `gorm:"not null"`
+
+	Lifecycle
 }
 
-type Case struct { //TODO: Refractor to fit CSGO API
-	ID    string `gorm:"primaryKey"`
-	Name  string `gorm:"unique;not null"`
-	Image string `gorm:"not null"`
+type CharmCollection struct {
+	CharmID      string `gorm:"primaryKey"`
+	CollectionID string `gorm:"primaryKey"`
+}
 
-	Stickers []Sticker `gorm:"foreignKey:CaseID"`
+type CaseType string
+
+// case types as named by the api, other values are stored as they are
+const (
+	WeaponCase      CaseType = "Case"
+	StickerCapsule  CaseType = "Sticker Capsule"
+	SouvenirPackage CaseType = "Souvenir Package"
+	GraffitiBox     CaseType = "Graffiti"
+)
+
+type Case struct {
+	ID    string   `gorm:"primaryKey"`
+	Name  string   `gorm:"unique;not null"`
+	Image string   `gorm:"not null"`
+	Type  CaseType // empty until the case is synced from the crates endpoint
+
+	Stickers []Sticker  `gorm:"foreignKey:CaseID"`
+	Drops    []CaseDrop `gorm:"foreignKey:CaseID;references:ID;constraint:OnDelete:CASCADE"`
 
 	Collection   *Collection `gorm:"foreignKey:CollectionId"`
 	CollectionId *string     //nullable to make populating easier
+
+	Lifecycle
+}
+
+// CaseDrop is an item that can be unboxed from a case
+// the item references the catalog row it resolves to, items that are not modeled (ex. graffiti) only keep their upstream id
+type CaseDrop struct {
+	CaseID   string  `gorm:"primaryKey"`
+	ItemID   string  `gorm:"primaryKey"` // upstream id of the item, ex. skin-... or sticker-...
+	Name     string  `gorm:"not null"`
+	Image    string  `gorm:"not null"`
+	Rare     bool    `gorm:"not null"` // rare special item, ex. knives and gloves
+	RarityId *string `gorm:"default:null"`
+	Rarity   *Rarity `gorm:"foreignKey:RarityId"`
+
+	SkinId    *string  `gorm:"default:null"`
+	StickerId *string  `gorm:"default:null"`
+	AgentId   *string  `gorm:"default:null"`
+	CharmId   *string  `gorm:"default:null"`
+	Skin      *Skin    `gorm:"foreignKey:SkinId;references:ID;constraint:OnDelete:SET NULL"`
+	Sticker   *Sticker `gorm:"foreignKey:StickerId;references:ID;constraint:OnDelete:SET NULL"`
+	Agent     *Agent   `gorm:"foreignKey:AgentId;references:ID;constraint:OnDelete:SET NULL"`
+	Charm     *Charm   `gorm:"foreignKey:CharmId;references:ID;constraint:OnDelete:SET NULL"`
 }
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Marketplace is a market whose prices are observed, ex. the steam community market
+type Marketplace struct {
+	ID       string `gorm:"primaryKey"` // ex. steam, skinport, csfloat or buff163
+	Name     string `gorm:"not null"`
+	URL      string
+	Currency string `gorm:"type:char(3);not null"` // ISO 4217 code the market lists its prices in
+}
+
+// PriceObservation is a snapshot of the prices of an item on a marketplace at a point in time
+// prices are in minor units of the currency (ex. cents) and are nil when the market didn't report them,
+// the table is partitioned by day and created by the database package, it is not auto migrated
+type PriceObservation struct {
+	ItemID        string      `gorm:"primaryKey"`
+	Item          Item        `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE"`
+	MarketplaceID string      `gorm:"primaryKey"`
+	Marketplace   Marketplace `gorm:"foreignKey:MarketplaceID;references:ID;constraint:OnDelete:CASCADE"`
+	ObservedAt    time.Time   `gorm:"primaryKey"`
+	Currency      string      `gorm:"type:char(3);not null"`
+
+	LowestAsk  *int64 // cheapest listing
+	HighestBid *int64 // best buy order
+	MedianSale *int64 // median price of the sales reported with the observation
+	Volume     *int32 // number of sales reported with the observation, usually over the last 24 hours
+}
+
+type CandleInterval string
+
+const (
+	Hour CandleInterval = "1h"
+	Day  CandleInterval = "1d"
+	Week CandleInterval = "1w" // weeks start on monday
+)
+
+var CandleIntervals = []CandleInterval{Hour, Day, Week}
+
+// PriceCandle rolls up the observations of an item on a marketplace over an interval starting at OpenAt (UTC),
+// the price of an observation is its lowest ask, else its median sale, else its highest bid
+type PriceCandle struct {
+	ItemID        string         `gorm:"primaryKey"`
+	Item          Item           `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE"`
+	MarketplaceID string         `gorm:"primaryKey"`
+	Marketplace   Marketplace    `gorm:"foreignKey:MarketplaceID;references:ID;constraint:OnDelete:CASCADE"`
+	Interval      CandleInterval `gorm:"column:candle_interval;primaryKey;index:idx_price_candles_open,priority:1"` // interval is a keyword of postgres
+	OpenAt        time.Time      `gorm:"primaryKey;index:idx_price_candles_open,priority:2"`
+	Currency      string         `gorm:"type:char(3);not null"` // currency of the last observation
+
+	Open  int64 `gorm:"not null"`
+	High  int64 `gorm:"not null"`
+	Low   int64 `gorm:"not null"`
+	Close int64 `gorm:"not null"`
+
+	Volume       int64 `gorm:"not null"` // largest volume snapshot of the observations, they report rolling 24 hour volumes
+	Observations int32 `gorm:"not null"` // number of observations rolled up
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Lifecycle tracks when a catalog row was seen in the upstream api
+type Lifecycle struct {
+	FirstSeen time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	LastSeen  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	RetiredAt *time.Time `gorm:"default:null"` // set once the row is missing from the upstream api
+}
+
+// PopulateRun is one execution of the populator
+type PopulateRun struct {
+	ID         uint      `gorm:"primaryKey"`
+	StartedAt  time.Time `gorm:"not null"`
+	FinishedAt *time.Time
+}
+
+// Retirement records a catalog row that disappeared upstream during a run
+type Retirement struct {
+	ID        uint        `gorm:"primaryKey"`
+	RunID     uint        `gorm:"not null;index"`
+	Run       PopulateRun `gorm:"foreignKey:RunID;references:ID;constraint:OnDelete:CASCADE"`
+	Entity    string      `gorm:"not null"` // table of the retired row
+	EntityID  string      `gorm:"not null"`
+	RetiredAt time.Time   `gorm:"not null"`
+}
+
+// RunPayload is the digest of an api endpoint as read by a run
+type RunPayload struct {
+	RunID    uint        `gorm:"primaryKey"`
+	Endpoint string      `gorm:"primaryKey"`
+	Digest   string      `gorm:"not null"` // sha256 of the payload tagged with the processing version, ex. 9f86...@v1
+	Run      PopulateRun `gorm:"foreignKey:RunID;references:ID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// DefaultLocale is the language of the names stored on the catalog rows themselves
+const DefaultLocale = "en"
+
+// Translation is the name of a catalog row in another language
+type Translation struct {
+	Entity   string `gorm:"primaryKey"` // table of the translated row, ex. skins
+	EntityID string `gorm:"primaryKey"`
+	Locale   string `gorm:"primaryKey;index"` // locale of the api, ex. de or zh-CN
+	Name     string `gorm:"not null"`
+}
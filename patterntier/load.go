@@ -0,0 +1,177 @@
+// Package patterntier imports a curated catalog of named paint seeds per skin, ex. Case Hardened blue gems,
+// and looks up the tier of item instances
+package patterntier
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxPaintSeed is the highest paint seed the game rolls
+const MaxPaintSeed = 1000
+
+var ErrInvalidSeed = errors.New("invalid paint seed")
+
+// Record names the seeds of a skin, the skin is its id or its name, ex. "★ Karambit | Case Hardened"
+//
+// in json files records are objects with a seeds array:
+//
+//	[{"skin": "★ Karambit | Case Hardened", "tier": "Blue Gem Tier 1", "seeds": [387, 442]}]
+//
+// in csv files the header is skin,tier,seeds and seeds are separated by spaces or semicolons,
+// both formats accept inclusive ranges like "650-660" in place of a seed
+type Record struct {
+	Skin  string
+	Tier  string
+	Seeds []uint16
+}
+
+type jsonRecord struct {
+	Skin  string            `json:"skin"`
+	Tier  string            `json:"tier"`
+	Seeds []json.RawMessage `json:"seeds"`
+}
+
+// Load reads the records of a .json or .csv file
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ReadJSON(f)
+	case ".csv":
+		return ReadCSV(f)
+	default:
+		return nil, fmt.Errorf("%s is neither a .json nor a .csv file", path)
+	}
+}
+
+func ReadJSON(r io.Reader) ([]Record, error) {
+	var raw []jsonRecord
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(raw))
+	for i, rec := range raw {
+		record := Record{Skin: rec.Skin, Tier: rec.Tier}
+		for _, seed := range rec.Seeds {
+			// a seed is either a number or a "from-to" range
+			var value string
+			if err := json.Unmarshal(seed, &value); err != nil {
+				value = string(seed)
+			}
+			seeds, err := parseSeeds(value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", i, err)
+			}
+			record.Seeds = append(record.Seeds, seeds...)
+		}
+		if err := record.validate(); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func ReadCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"skin", "tier", "seeds"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := Record{Skin: row[columns["skin"]], Tier: row[columns["tier"]]}
+		for _, value := range strings.FieldsFunc(row[columns["seeds"]], func(r rune) bool { return r == ' ' || r == ';' }) {
+			seeds, err := parseSeeds(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			record.Seeds = append(record.Seeds, seeds...)
+		}
+		if err := record.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseSeeds parses a seed or an inclusive range of seeds
+func parseSeeds(value string) ([]uint16, error) {
+	value = strings.TrimSpace(value)
+	from, to, isRange := strings.Cut(value, "-")
+	first, err := parseSeed(from)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return []uint16{first}, nil
+	}
+	last, err := parseSeed(to)
+	if err != nil {
+		return nil, err
+	}
+	if last < first {
+		return nil, fmt.Errorf("%w: range %s is reversed", ErrInvalidSeed, value)
+	}
+
+	seeds := make([]uint16, 0, last-first+1)
+	for seed := first; seed <= last; seed++ {
+		seeds = append(seeds, seed)
+	}
+	return seeds, nil
+}
+
+func parseSeed(value string) (uint16, error) {
+	seed, err := strconv.ParseUint(strings.TrimSpace(value), 10, 16)
+	if err != nil || seed > MaxPaintSeed {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSeed, value)
+	}
+	return uint16(seed), nil
+}
+
+func (r Record) validate() error {
+	switch {
+	case strings.TrimSpace(r.Skin) == "":
+		return errors.New("missing skin")
+	case strings.TrimSpace(r.Tier) == "":
+		return errors.New("missing tier")
+	case len(r.Seeds) == 0:
+		return errors.New("missing seeds")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package patterntier
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Record
+	}{
+		{
+			"numbers and ranges",
+			`[{"skin": "★ Karambit | Case Hardened", "tier": "Blue Gem Tier 1", "seeds": [387, "442-443", "1000"]}]`,
+			[]Record{{Skin: "★ Karambit | Case Hardened", Tier: "Blue Gem Tier 1", Seeds: []uint16{387, 442, 443, 1000}}},
+		},
+		{
+			"skin ids and names",
+			`[
+				{"skin": "skin-65604", "tier": "Blue Gem", "seeds": [661]},
+				{"skin": "AK-47 | Case Hardened", "tier": "Blue Gem", "seeds": ["0", " 151 - 152 "]}
+			]`,
+			[]Record{
+				{Skin: "skin-65604", Tier: "Blue Gem", Seeds: []uint16{661}},
+				{Skin: "AK-47 | Case Hardened", Tier: "Blue Gem", Seeds: []uint16{0, 151, 152}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadJSON(strings.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	const data = `Skin,Tier,Seeds
+★ Karambit | Case Hardened,Blue Gem Tier 1,387 442-443
+skin-65604,Blue Gem,661;670-671
+AK-47 | Case Hardened, Gold, 151 ; 152
+`
+	want := []Record{
+		{Skin: "★ Karambit | Case Hardened", Tier: "Blue Gem Tier 1", Seeds: []uint16{387, 442, 443}},
+		{Skin: "skin-65604", Tier: "Blue Gem", Seeds: []uint16{661, 670, 671}},
+		{Skin: "AK-47 | Case Hardened", Tier: "Gold", Seeds: []uint16{151, 152}},
+	}
+	got, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(string) ([]Record, error)
+		data string
+		err  error // nil when any error will do
+	}{
+		{"reversed range", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": ["443-442"]}]`, ErrInvalidSeed},
+		{"seed above the max", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": [1001]}]`, ErrInvalidSeed},
+		{"negative seed", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": [-1]}]`, ErrInvalidSeed},
+		{"fractional seed", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": [1.5]}]`, ErrInvalidSeed},
+		{"open range", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": ["442-"]}]`, ErrInvalidSeed},
+		{"no seeds", jsonRecords, `[{"skin": "s", "tier": "t", "seeds": []}]`, nil},
+		{"no skin", jsonRecords, `[{"tier": "t", "seeds": [1]}]`, nil},
+		{"reversed csv range", csvRecords, "skin,tier,seeds\ns,t,10-5\n", ErrInvalidSeed},
+		{"csv seed above the max", csvRecords, "skin,tier,seeds\ns,t,1 2000\n", ErrInvalidSeed},
+		{"comma separated seeds", csvRecords, "skin,tier,seeds\ns,t,\"1,2\"\n", ErrInvalidSeed},
+		{"missing column", csvRecords, "skin,tier\ns,t\n", nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.read(tt.data)
+		if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %+v, %v, want %v", tt.name, got, err, tt.err)
+		}
+	}
+}
+
+func jsonRecords(data string) ([]Record, error) { return ReadJSON(strings.NewReader(data)) }
+func csvRecords(data string) ([]Record, error)  { return ReadCSV(strings.NewReader(data)) }
@@ -0,0 +1,127 @@
+package patterntier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"github.com/massimomarsiglia/cs-skins-market-models/prices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoPaintSeed = errors.New("item attributes have no paint seed")
+
+type tierKey struct {
+	skinID string
+	seed   uint16
+}
+
+// ImportResult counts the stored tiers and lists the skins of the records that are not in the skins table
+type ImportResult struct {
+	Imported int
+	Unknown  []string
+}
+
+// Import replaces the tiers of every skin of the records, skins not in the records keep their tiers
+// so importing the same file twice leaves the table unchanged
+// a skin name matches every skin with that name, ex. the phases of a Doppler,
+// when a seed of a skin is listed by several records the first one wins
+func Import(records []Record, tx *gorm.DB) (ImportResult, error) {
+	keys := make([]string, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, record.Skin)
+	}
+
+	var skins []models.Skin
+	if err := tx.Select("id", "name").Where("id IN ? OR name IN ?", keys, keys).Find(&skins).Error; err != nil {
+		return ImportResult{}, err
+	}
+	skinIDs := make(map[string][]string, len(skins))
+	for _, skin := range skins {
+		skinIDs[skin.ID] = append(skinIDs[skin.ID], skin.ID)
+		if skin.Name != skin.ID {
+			skinIDs[skin.Name] = append(skinIDs[skin.Name], skin.ID)
+		}
+	}
+
+	var result ImportResult
+	var tiers []models.PatternTier
+	seen := make(map[tierKey]struct{})
+	unknown := make(map[string]struct{})
+	for _, record := range records {
+		ids, ok := skinIDs[record.Skin]
+		if !ok {
+			unknown[record.Skin] = struct{}{}
+			continue
+		}
+		for _, id := range ids {
+			for _, seed := range record.Seeds {
+				key := tierKey{id, seed}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				tiers = append(tiers, models.PatternTier{SkinId: id, PaintSeed: seed, Tier: record.Tier})
+			}
+		}
+	}
+	for skin := range unknown {
+		result.Unknown = append(result.Unknown, skin)
+	}
+	sort.Strings(result.Unknown)
+
+	imported := make([]string, 0, len(skins))
+	for _, skin := range skins {
+		imported = append(imported, skin.ID)
+	}
+	if len(imported) == 0 {
+		return result, nil
+	}
+
+	if err := tx.Where("skin_id IN ?", imported).Delete(&models.PatternTier{}).Error; err != nil {
+		return ImportResult{}, err
+	}
+	if len(tiers) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(tiers, prices.DefaultBatchSize).Error; err != nil {
+			return ImportResult{}, err
+		}
+	}
+	result.Imported = len(tiers)
+	return result, nil
+}
+
+// Tier returns the tier of a paint seed of a skin, gorm.ErrRecordNotFound if the seed has none
+func Tier(skinID string, seed uint16, tx *gorm.DB) (models.PatternTier, error) {
+	var tier models.PatternTier
+	if err := tx.Where("skin_id = ? AND paint_seed = ?", skinID, seed).First(&tier).Error; err != nil {
+		return models.PatternTier{}, err
+	}
+	return tier, nil
+}
+
+// TierOf returns the tier of an item instance, its item is expected to be an item skin
+// ok is false when the paint seed of the instance is not in the catalog
+func TierOf(a *models.ItemAttributes, tx *gorm.DB) (tier string, ok bool, err error) {
+	if a.PaintSeed == nil {
+		return "", false, ErrNoPaintSeed
+	}
+
+	var skinIDs []string
+	if err := tx.Model(&models.ItemSkin{}).Where("id = ?", a.ItemID).Limit(1).Pluck("skin_id", &skinIDs).Error; err != nil {
+		return "", false, err
+	}
+	if len(skinIDs) == 0 {
+		return "", false, fmt.Errorf("%s is not an item skin", a.ItemID)
+	}
+
+	t, err := Tier(skinIDs[0], *a.PaintSeed, tx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return t.Tier, true, nil
+}
@@ -0,0 +1,110 @@
+package patterntier
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/database"
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// testDB migrates the database of DATABASE_URL, the test is skipped without one
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	if database.DB == nil {
+		database.InitDB()
+	}
+	return database.DB
+}
+
+// storeSkins creates two Case Hardened skins sharing a name and another skin with the catalog rows they reference
+func storeSkins(t *testing.T, tx *gorm.DB) {
+	t.Helper()
+	rows := []any{
+		&models.Rarity{ID: "test-rarity", Name: "Covert"},
+		&models.Weapon{ID: "test-weapon", Name: "Test Knife"},
+		&models.Category{ID: "test-category", Name: "Test Knives"},
+		&models.Pattern{ID: "test-pattern", Name: "Case Hardened"},
+		&models.Team{ID: "test-team", Name: "Test Team"},
+	}
+	for _, id := range []string{"test-skin-1", "test-skin-2", "test-skin-3"} {
+		name := "★ Test Knife | Case Hardened"
+		if id == "test-skin-3" {
+			name = "★ Test Knife | Fade"
+		}
+		rows = append(rows, &models.Skin{
+			ID: id, Name: name, WeaponId: "test-weapon", RarityId: "test-rarity",
+			CategoryId: "test-category", PatternId: "test-pattern", TeamId: "test-team", MaxFloat: 1,
+		})
+	}
+	for _, row := range rows {
+		if err := tx.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// tiers returns the stored tiers of the test skins keyed by skin and seed
+func tiers(t *testing.T, tx *gorm.DB) map[string]map[uint16]string {
+	t.Helper()
+	var stored []models.PatternTier
+	if err := tx.Where("skin_id LIKE ?", "test-skin-%").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	bySkin := make(map[string]map[uint16]string)
+	for _, tier := range stored {
+		if bySkin[tier.SkinId] == nil {
+			bySkin[tier.SkinId] = make(map[uint16]string)
+		}
+		bySkin[tier.SkinId][tier.PaintSeed] = tier.Tier
+	}
+	return bySkin
+}
+
+func TestImport(t *testing.T) {
+	db := testDB(t)
+	tx := db.Begin()
+	defer tx.Rollback()
+	storeSkins(t, tx)
+
+	old := []Record{
+		{Skin: "★ Test Knife | Case Hardened", Tier: "Blue Gem", Seeds: []uint16{1, 2}},
+		{Skin: "test-skin-3", Tier: "Max Fade", Seeds: []uint16{3}},
+	}
+	if _, err := Import(old, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the Case Hardened tiers are replaced, skin 1 by id and both by name, the Fade is not imported and keeps its tier
+	records := []Record{
+		{Skin: "test-skin-1", Tier: "Tier 1", Seeds: []uint16{5}},
+		{Skin: "★ Test Knife | Case Hardened", Tier: "Tier 2", Seeds: []uint16{5, 6}},
+		{Skin: "★ Test Knife | Doppler", Tier: "Ruby", Seeds: []uint16{7}},
+	}
+	want := map[string]map[uint16]string{
+		"test-skin-1": {5: "Tier 1", 6: "Tier 2"},
+		"test-skin-2": {5: "Tier 2", 6: "Tier 2"},
+		"test-skin-3": {3: "Max Fade"},
+	}
+	for range 2 {
+		result, err := Import(records, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Imported != 4 || !reflect.DeepEqual(result.Unknown, []string{"★ Test Knife | Doppler"}) {
+			t.Errorf("Import = %+v", result)
+		}
+		if got := tiers(t, tx); !reflect.DeepEqual(got, want) {
+			t.Errorf("stored tiers %v, want %v", got, want)
+		}
+	}
+
+	if tier, err := Tier("test-skin-2", 6, tx); err != nil || tier.Tier != "Tier 2" {
+		t.Errorf("Tier = %+v, %v", tier, err)
+	}
+}
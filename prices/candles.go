@@ -0,0 +1,204 @@
+package prices
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// Truncate returns the start of the candle of the interval holding t, in UTC
+func Truncate(i models.CandleInterval, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	switch i {
+	case models.Hour:
+		return t.Truncate(time.Hour), nil
+	case models.Day:
+		return t.Truncate(24 * time.Hour), nil
+	case models.Week:
+		day := t.Truncate(24 * time.Hour)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown candle interval %q", i)
+	}
+}
+
+// next returns the start of the candle following the one starting at t
+func next(i models.CandleInterval, t time.Time) time.Time {
+	switch i {
+	case models.Hour:
+		return t.Add(time.Hour)
+	case models.Day:
+		return t.AddDate(0, 0, 1)
+	default:
+		return t.AddDate(0, 0, 7)
+	}
+}
+
+// units of date_trunc for each interval
+var truncUnits = map[models.CandleInterval]string{
+	models.Hour: "hour",
+	models.Day:  "day",
+	models.Week: "week",
+}
+
+// RollupRange recomputes the candles of every interval holding a time in [from, to] from the observations,
+// candles are recomputed whole so it can be run again for late observations, ex. after an import
+// it returns the number of candles written
+func (r *Repository) RollupRange(from, to time.Time, tx *gorm.DB) (int64, error) {
+	var written int64
+	for _, interval := range models.CandleIntervals {
+		n, err := r.rollup(interval, from, to, tx)
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// Rollup rolls the new observations up incrementally, for each interval the candles from Lookback before
+// the last stored one, which may still have been open, are recomputed along with every candle after it up to now
+// observations older than that are only rolled up by RollupRange, as importing a dump does
+func (r *Repository) Rollup(now time.Time, tx *gorm.DB) (int64, error) {
+	var written int64
+	for _, interval := range models.CandleIntervals {
+		var last sql.NullTime
+		if err := tx.Model(&models.PriceCandle{}).Where("candle_interval = ?", interval).Select("max(open_at)").Row().Scan(&last); err != nil {
+			return written, err
+		}
+		if !last.Valid {
+			// first rollup, start at the first observation
+			if err := tx.Table("price_observations").Select("min(observed_at)").Row().Scan(&last); err != nil {
+				return written, err
+			}
+			if !last.Valid {
+				return written, nil
+			}
+		}
+
+		n, err := r.rollup(interval, last.Time.Add(-r.Lookback), now, tx)
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func (r *Repository) rollup(interval models.CandleInterval, from, to time.Time, tx *gorm.DB) (int64, error) {
+	start, err := Truncate(interval, from)
+	if err != nil {
+		return 0, err
+	}
+	end, err := Truncate(interval, to)
+	if err != nil {
+		return 0, err
+	}
+	end = next(interval, end)
+
+	// the open and close are the prices of the first and last observation of the candle,
+	// the volumes are rolling 24 hour snapshots so summing them would count the same sales again
+	result := tx.Exec(`
+        INSERT INTO price_candles (item_id, marketplace_id, candle_interval, open_at, currency, open, high, low, close, volume, observations)
+        SELECT item_id, marketplace_id, @interval, open_at,
+            (array_agg(currency ORDER BY observed_at DESC))[1],
+            (array_agg(price ORDER BY observed_at))[1],
+            max(price),
+            min(price),
+            (array_agg(price ORDER BY observed_at DESC))[1],
+            COALESCE(max(volume), 0),
+            count(*)
+        FROM (
+            SELECT item_id, marketplace_id, currency, volume, observed_at,
+                date_trunc(@unit, observed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS open_at,
+                COALESCE(lowest_ask, median_sale, highest_bid) AS price
+            FROM price_observations
+            WHERE observed_at >= @start AND observed_at < @end
+        ) o
+        WHERE price IS NOT NULL
+        GROUP BY item_id, marketplace_id, open_at
+        ON CONFLICT (item_id, marketplace_id, candle_interval, open_at) DO UPDATE SET
+            currency = EXCLUDED.currency,
+            open = EXCLUDED.open,
+            high = EXCLUDED.high,
+            low = EXCLUDED.low,
+            close = EXCLUDED.close,
+            volume = EXCLUDED.volume,
+            observations = EXCLUDED.observations
+    `, map[string]any{
+		"interval": interval,
+		"unit":     truncUnits[interval],
+		"start":    start,
+		"end":      end,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// Candles returns the candles of an item on a marketplace opening in [from, to), ordered by time
+func (r *Repository) Candles(itemID, marketplaceID string, interval models.CandleInterval, from, to time.Time, tx *gorm.DB) ([]models.PriceCandle, error) {
+	var candles []models.PriceCandle
+	if err := tx.Where("item_id = ? AND marketplace_id = ? AND candle_interval = ? AND open_at >= ? AND open_at < ?",
+		itemID, marketplaceID, interval, from, to).
+		Order("open_at").
+		Find(&candles).Error; err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+// Chart returns at most points candles covering [from, to) for a chart, it uses the finest interval whose candles fit
+// and merges consecutive weekly candles when even those don't, merged candles keep the interval and open time of their first one
+func (r *Repository) Chart(itemID, marketplaceID string, from, to time.Time, points int, tx *gorm.DB) ([]models.PriceCandle, error) {
+	if points <= 0 {
+		return nil, fmt.Errorf("invalid number of points %d", points)
+	}
+
+	interval := models.Week
+	for _, i := range models.CandleIntervals {
+		start, err := Truncate(i, from)
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for t := start; t.Before(to) && n <= points; t = next(i, t) {
+			n++
+		}
+		if n <= points {
+			interval = i
+			break
+		}
+	}
+
+	candles, err := r.Candles(itemID, marketplaceID, interval, from, to, tx)
+	if err != nil {
+		return nil, err
+	}
+	return Downsample(candles, points), nil
+}
+
+// Downsample merges consecutive candles so at most points remain
+func Downsample(candles []models.PriceCandle, points int) []models.PriceCandle {
+	if points <= 0 || len(candles) <= points {
+		return candles
+	}
+
+	size := (len(candles) + points - 1) / points
+	merged := make([]models.PriceCandle, 0, points)
+	for start := 0; start < len(candles); start += size {
+		group := candles[start:min(start+size, len(candles))]
+		candle := group[0]
+		for _, c := range group[1:] {
+			candle.High = max(candle.High, c.High)
+			candle.Low = min(candle.Low, c.Low)
+			candle.Close = c.Close
+			candle.Currency = c.Currency
+			candle.Volume = max(candle.Volume, c.Volume)
+			candle.Observations += c.Observations
+		}
+		merged = append(merged, candle)
+	}
+	return merged
+}
@@ -0,0 +1,47 @@
+package prices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+func TestTruncate(t *testing.T) {
+	at := time.Date(2026, 10, 18, 15, 42, 7, 0, time.FixedZone("CEST", 2*60*60)) // a sunday
+	tests := []struct {
+		interval models.CandleInterval
+		want     time.Time
+	}{
+		{models.Hour, time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC)},
+		{models.Day, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{models.Week, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := Truncate(tt.interval, at)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("Truncate(%s) = %v, %v, want %v", tt.interval, got, err, tt.want)
+		}
+	}
+	if _, err := Truncate("1m", at); err == nil {
+		t.Error("unknown interval accepted")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	candles := []models.PriceCandle{
+		{OpenAt: start, Open: 100, High: 120, Low: 90, Close: 110, Volume: 30, Observations: 24},
+		{OpenAt: start.AddDate(0, 0, 1), Open: 110, High: 150, Low: 105, Close: 140, Volume: 50, Observations: 24},
+		{OpenAt: start.AddDate(0, 0, 2), Open: 140, High: 145, Low: 80, Close: 85, Volume: 40, Observations: 24},
+	}
+
+	merged := Downsample(candles, 1)
+	want := models.PriceCandle{OpenAt: start, Open: 100, High: 150, Low: 80, Close: 85, Volume: 50, Observations: 72}
+	if len(merged) != 1 || merged[0] != want {
+		t.Errorf("Downsample = %+v, want %+v", merged, want)
+	}
+	if got := Downsample(candles, 3); len(got) != 3 {
+		t.Errorf("Downsample to as many points merged %d candles", 3-len(got))
+	}
+}
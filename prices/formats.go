@@ -0,0 +1,298 @@
+package prices
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(steamFormat{}) // see CurrencyFormat
+	Register(skinportFormat{})
+	Register(csfloatFormat{})
+	Register(buffFormat{})
+	Register(buffCSVFormat{})
+}
+
+// steamFormat reads an object of price overviews of the steam market keyed by market hash name,
+// prices are formatted in the wallet currency of the account, which the dump doesn't tell, ex.
+//
+//	{"AK-47 | Redline (Field-Tested)": {"lowest_price": "$12.34", "median_price": "$12.01", "volume": "1,234"}}
+type steamFormat struct {
+	currency string
+}
+
+func (steamFormat) Name() string        { return "steam" }
+func (steamFormat) Marketplace() string { return "steam" }
+
+func (steamFormat) WithCurrency(currency string) Format {
+	return steamFormat{currency: strings.ToUpper(currency)}
+}
+
+func (f steamFormat) Parse(r io.Reader) ([]Entry, []Reject, error) {
+	if f.currency == "" {
+		return nil, nil, fmt.Errorf("%w: steam prices are in the wallet currency of the account", ErrNoCurrency)
+	}
+
+	var dump map[string]struct {
+		LowestPrice string `json:"lowest_price"`
+		MedianPrice string `json:"median_price"`
+		Volume      string `json:"volume"`
+	}
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, nil, err
+	}
+
+	// objects have no order, rows follow the names
+	names := make([]string, 0, len(dump))
+	for name := range dump {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var entries []Entry
+	var rejects []Reject
+	for i, name := range names {
+		price := dump[name]
+		entry := Entry{Row: i + 1, MarketHashName: name, Currency: f.currency}
+		var errs [3]error
+		entry.LowestAsk, errs[0] = optionalMinorUnits(price.LowestPrice, f.currency)
+		entry.MedianSale, errs[1] = optionalMinorUnits(price.MedianPrice, f.currency)
+		entry.Volume, errs[2] = count(price.Volume)
+		if err := errors.Join(errs[:]...); err != nil {
+			rejects = append(rejects, Reject{entry.Row, name, err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rejects, nil
+}
+
+// skinportFormat reads the items or the sales history of the skinport api, prices are numbers in major units, ex.
+//
+//	[{"market_hash_name": "...", "currency": "EUR", "min_price": 12.5, "median_price": 13, "updated_at": 1760745600}]
+//	[{"market_hash_name": "...", "currency": "EUR", "last_24_hours": {"median": 12.9, "volume": 31}}]
+type skinportFormat struct{}
+
+func (skinportFormat) Name() string        { return "skinport" }
+func (skinportFormat) Marketplace() string { return "skinport" }
+
+func (skinportFormat) Parse(r io.Reader) ([]Entry, []Reject, error) {
+	var dump []struct {
+		MarketHashName string       `json:"market_hash_name"`
+		Currency       string       `json:"currency"`
+		MinPrice       *json.Number `json:"min_price"`
+		MedianPrice    *json.Number `json:"median_price"`
+		UpdatedAt      int64        `json:"updated_at"`
+		Last24Hours    *struct {
+			Median *json.Number `json:"median"`
+			Volume *int32       `json:"volume"`
+		} `json:"last_24_hours"`
+	}
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []Entry
+	var rejects []Reject
+	for i, item := range dump {
+		entry := Entry{Row: i + 1, MarketHashName: item.MarketHashName, Currency: item.Currency}
+		if item.UpdatedAt != 0 {
+			entry.ObservedAt = time.Unix(item.UpdatedAt, 0)
+		}
+		currency := cmp.Or(item.Currency, marketplaceCurrency("skinport"))
+
+		var errs [2]error
+		entry.LowestAsk, errs[0] = numberMinorUnits(item.MinPrice, currency)
+		if item.Last24Hours != nil {
+			// sales history, the median is the one of the sales
+			entry.MedianSale, errs[1] = numberMinorUnits(item.Last24Hours.Median, currency)
+			entry.Volume = item.Last24Hours.Volume
+		} else {
+			entry.MedianSale, errs[1] = numberMinorUnits(item.MedianPrice, currency)
+		}
+		if err := errors.Join(errs[:]...); err != nil {
+			rejects = append(rejects, Reject{entry.Row, item.MarketHashName, err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rejects, nil
+}
+
+// csfloatFormat reads the listings of the csfloat api, bare or in a data object, prices are in cents, ex.
+//
+//	{"data": [{"price": 1234, "item": {"market_hash_name": "..."}}]}
+//
+// the lowest listing of each item is its lowest ask
+type csfloatFormat struct{}
+
+func (csfloatFormat) Name() string        { return "csfloat" }
+func (csfloatFormat) Marketplace() string { return "csfloat" }
+
+type csfloatListing struct {
+	Price *int64 `json:"price"`
+	Item  struct {
+		MarketHashName string `json:"market_hash_name"`
+	} `json:"item"`
+}
+
+func (csfloatFormat) Parse(r io.Reader) ([]Entry, []Reject, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var listings []csfloatListing
+	if err := json.Unmarshal(raw, &listings); err != nil {
+		var page struct {
+			Data []csfloatListing `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, nil, err
+		}
+		listings = page.Data
+	}
+
+	// an entry per item at the row of its first listing
+	index := make(map[string]int)
+	var entries []Entry
+	var rejects []Reject
+	for i, listing := range listings {
+		name := listing.Item.MarketHashName
+		if listing.Price == nil || *listing.Price <= 0 {
+			rejects = append(rejects, Reject{i + 1, name, "listing without a price"})
+			continue
+		}
+		j, ok := index[name]
+		if !ok {
+			price := *listing.Price
+			index[name] = len(entries)
+			entries = append(entries, Entry{Row: i + 1, MarketHashName: name, LowestAsk: &price})
+			continue
+		}
+		if *listing.Price < *entries[j].LowestAsk {
+			*entries[j].LowestAsk = *listing.Price
+		}
+	}
+	return entries, rejects, nil
+}
+
+type buffItem struct {
+	MarketHashName string `json:"market_hash_name"`
+	SellMinPrice   string `json:"sell_min_price"`
+	BuyMaxPrice    string `json:"buy_max_price"`
+}
+
+func (i buffItem) entry(row int) (Entry, error) {
+	entry := Entry{Row: row, MarketHashName: i.MarketHashName}
+	currency := marketplaceCurrency("buff163")
+	var errs [2]error
+	entry.LowestAsk, errs[0] = optionalMinorUnits(i.SellMinPrice, currency)
+	entry.HighestBid, errs[1] = optionalMinorUnits(i.BuyMaxPrice, currency)
+	return entry, errors.Join(errs[:]...)
+}
+
+// buffFormat reads the goods of the buff api, bare or in its data object, prices are strings in yuan, ex.
+//
+//	{"data": {"items": [{"market_hash_name": "...", "sell_min_price": "85.5", "buy_max_price": "80"}]}}
+type buffFormat struct{}
+
+func (buffFormat) Name() string        { return "buff" }
+func (buffFormat) Marketplace() string { return "buff163" }
+
+func (buffFormat) Parse(r io.Reader) ([]Entry, []Reject, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []buffItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		var page struct {
+			Data struct {
+				Items []buffItem `json:"items"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, nil, err
+		}
+		items = page.Data.Items
+	}
+
+	var entries []Entry
+	var rejects []Reject
+	for i, item := range items {
+		entry, err := item.entry(i + 1)
+		if err != nil {
+			rejects = append(rejects, Reject{entry.Row, item.MarketHashName, err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rejects, nil
+}
+
+// buffCSVFormat reads buff goods exported as csv with the columns of the api,
+// market_hash_name and sell_min_price are required, buy_max_price and observed_at (RFC 3339) are optional
+type buffCSVFormat struct{}
+
+func (buffCSVFormat) Name() string        { return "buff-csv" }
+func (buffCSVFormat) Marketplace() string { return "buff163" }
+
+func (buffCSVFormat) Parse(r io.Reader) ([]Entry, []Reject, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"market_hash_name", "sell_min_price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+	column := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var entries []Entry
+	var rejects []Reject
+	for i := 1; ; i++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item := buffItem{
+			MarketHashName: column(row, "market_hash_name"),
+			SellMinPrice:   column(row, "sell_min_price"),
+			BuyMaxPrice:    column(row, "buy_max_price"),
+		}
+		entry, err := item.entry(i)
+		if at := column(row, "observed_at"); at != "" && err == nil {
+			entry.ObservedAt, err = time.Parse(time.RFC3339, at)
+		}
+		if err != nil {
+			rejects = append(rejects, Reject{i, item.MarketHashName, err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rejects, nil
+}
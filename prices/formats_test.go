@@ -0,0 +1,66 @@
+package prices
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSteamFormatCurrency(t *testing.T) {
+	const dump = `{
+		"AK-47 | Redline (Field-Tested)": {"lowest_price": "12,34€", "median_price": "1.234,56€", "volume": "1,234"},
+		"AWP | Asiimov (Battle-Scarred)": {"lowest_price": "free"}
+	}`
+
+	f, err := LookupFormat("steam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := f.Parse(strings.NewReader(dump)); !errors.Is(err, ErrNoCurrency) {
+		t.Fatalf("Parse without a currency = %v, want %v", err, ErrNoCurrency)
+	}
+
+	cf, ok := f.(CurrencyFormat)
+	if !ok {
+		t.Fatal("steam is not a CurrencyFormat")
+	}
+	entries, rejects, err := cf.WithCurrency("eur").Parse(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(rejects) != 1 || rejects[0].MarketHashName != "AWP | Asiimov (Battle-Scarred)" {
+		t.Fatalf("entries %+v, rejects %+v", entries, rejects)
+	}
+	e := entries[0]
+	if e.Currency != "EUR" || *e.LowestAsk != 1234 || *e.MedianSale != 123456 || *e.Volume != 1234 {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// yen have no minor digits
+	const yen = `{"AK-47 | Redline (Field-Tested)": {"lowest_price": "¥ 1,234", "median_price": "¥ 1,180"}}`
+	entries, _, err = cf.WithCurrency("JPY").Parse(strings.NewReader(yen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || *entries[0].LowestAsk != 1234 || *entries[0].MedianSale != 1180 {
+		t.Errorf("yen entries %+v", entries)
+	}
+}
+
+func TestSkinportFormatNumbers(t *testing.T) {
+	const dump = `[
+		{"market_hash_name": "AK-47 | Redline (Field-Tested)", "currency": "EUR", "min_price": 12.345, "median_price": 1.2300000000000002},
+		{"market_hash_name": "AWP | Asiimov (Battle-Scarred)", "currency": "EUR", "last_24_hours": {"median": 0.1234, "volume": 31}}
+	]`
+
+	entries, rejects, err := skinportFormat{}.Parse(strings.NewReader(dump))
+	if err != nil || len(rejects) != 0 || len(entries) != 2 {
+		t.Fatalf("entries %+v, rejects %+v, err %v", entries, rejects, err)
+	}
+	if *entries[0].LowestAsk != 1235 || *entries[0].MedianSale != 123 {
+		t.Errorf("entry = %+v", entries[0])
+	}
+	if *entries[1].MedianSale != 12 || *entries[1].Volume != 31 {
+		t.Errorf("entry = %+v", entries[1])
+	}
+}
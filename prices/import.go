@@ -0,0 +1,207 @@
+package prices
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// Entry is the price of an item in a dump, prices are in minor units of the currency
+type Entry struct {
+	Row            int // position in the dump starting at 1, used by the reject report
+	MarketHashName string
+	ObservedAt     time.Time // zero when the dump has no time, the time of the import is used instead
+	Currency       string    // empty for the currency of the marketplace
+
+	LowestAsk  *int64
+	HighestBid *int64
+	MedianSale *int64
+	Volume     *int32
+}
+
+// Reject is an entry of a dump that was not imported
+type Reject struct {
+	Row            int
+	MarketHashName string
+	Reason         string
+}
+
+// Format parses the price dumps of a marketplace
+// entries that can't be parsed are rejected, an error is returned only when the dump itself is unreadable
+type Format interface {
+	Name() string        // name the format is registered with, ex. skinport
+	Marketplace() string // id of the marketplace, see Marketplaces
+	Parse(r io.Reader) ([]Entry, []Reject, error)
+}
+
+// CurrencyFormat is a format whose dumps don't tell their currency, it must be given before parsing
+type CurrencyFormat interface {
+	Format
+	WithCurrency(currency string) Format // ISO 4217 code, ex. EUR
+}
+
+var (
+	ErrNoCurrency   = errors.New("the currency of the dump must be given")
+	ErrNoObservedAt = errors.New("the dump has entries without a time, the time of the dump must be given")
+)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// Register makes a format available to LookupFormat, registering a name twice replaces the format
+func Register(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[f.Name()] = f
+}
+
+// LookupFormat returns the format registered with the name
+func LookupFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if f, ok := formats[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown price dump format %q, known formats are %v", name, FormatNames())
+}
+
+// FormatNames returns the names of the registered formats, sorted
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ImportResult counts the entries of a dump and lists the rejected ones
+type ImportResult struct {
+	Marketplace string
+	Entries     int
+	Recorded    int
+	Rejects     []Reject
+
+	From, To time.Time // first and last time of the recorded observations, see RollupRange
+}
+
+// Import parses a dump and records its entries as observations of the items with the same market hash name,
+// observedAt is the time of the entries without one so importing the same dump with the same time twice
+// overwrites the observations instead of duplicating them, the marketplace is created if it doesn't exist
+// ErrNoObservedAt is returned when observedAt is zero and an entry has no time
+func (r *Repository) Import(f Format, src io.Reader, observedAt time.Time, tx *gorm.DB) (ImportResult, error) {
+	entries, rejects, err := f.Parse(src)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result := ImportResult{Marketplace: f.Marketplace(), Entries: len(entries) + len(rejects), Rejects: rejects}
+
+	marketplace, err := r.marketplace(f.Marketplace(), tx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.MarketHashName)
+	}
+	items, err := r.itemIDs(names, tx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	observations := make([]models.PriceObservation, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := items[entry.MarketHashName]
+		switch {
+		case !ok:
+			result.Rejects = append(result.Rejects, Reject{entry.Row, entry.MarketHashName, "unknown market hash name"})
+			continue
+		case entry.LowestAsk == nil && entry.HighestBid == nil && entry.MedianSale == nil:
+			result.Rejects = append(result.Rejects, Reject{entry.Row, entry.MarketHashName, "no price"})
+			continue
+		}
+
+		observation := models.PriceObservation{
+			ItemID:        id,
+			MarketplaceID: marketplace.ID,
+			ObservedAt:    entry.ObservedAt,
+			Currency:      entry.Currency,
+			LowestAsk:     entry.LowestAsk,
+			HighestBid:    entry.HighestBid,
+			MedianSale:    entry.MedianSale,
+			Volume:        entry.Volume,
+		}
+		if observation.ObservedAt.IsZero() {
+			if observedAt.IsZero() {
+				return ImportResult{}, fmt.Errorf("%w: row %d %q", ErrNoObservedAt, entry.Row, entry.MarketHashName)
+			}
+			observation.ObservedAt = observedAt
+		}
+		if observation.Currency == "" {
+			observation.Currency = marketplace.Currency
+		}
+		observations = append(observations, observation)
+
+		if result.From.IsZero() || observation.ObservedAt.Before(result.From) {
+			result.From = observation.ObservedAt
+		}
+		if observation.ObservedAt.After(result.To) {
+			result.To = observation.ObservedAt
+		}
+	}
+	slices.SortStableFunc(result.Rejects, func(a, b Reject) int { return a.Row - b.Row })
+
+	recorded, err := r.Record(observations, tx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result.Recorded = recorded
+	return result, nil
+}
+
+// marketplace returns the stored marketplace, known marketplaces missing from the database are created
+func (r *Repository) marketplace(id string, tx *gorm.DB) (models.Marketplace, error) {
+	for _, m := range Marketplaces {
+		if m.ID == id {
+			if err := tx.FirstOrCreate(&m, models.Marketplace{ID: id}).Error; err != nil {
+				return models.Marketplace{}, err
+			}
+			return m, nil
+		}
+	}
+
+	var m models.Marketplace
+	if err := tx.First(&m, "id = ?", id).Error; err != nil {
+		return models.Marketplace{}, fmt.Errorf("marketplace %s: %w", id, err)
+	}
+	return m, nil
+}
+
+// itemIDs returns the ids of the items by market hash name, names without an item are left out
+func (r *Repository) itemIDs(names []string, tx *gorm.DB) (map[string]string, error) {
+	ids := make(map[string]string, len(names))
+	n := r.batchSize()
+	for start := 0; start < len(names); start += n {
+		end := min(start+n, len(names))
+
+		var items []models.Item
+		if err := tx.Select("id", "market_hash_name").Where("market_hash_name IN ?", names[start:end]).Find(&items).Error; err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			ids[item.MarketHashName] = item.ID
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,150 @@
+package prices
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidPrice = errors.New("invalid price")
+
+// exponents are the ISO 4217 exponents of the currencies that don't have two minor digits
+var exponents = map[string]int{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+// MinorDigits returns the number of minor digits of an ISO 4217 currency, 2 for unknown ones
+func MinorDigits(currency string) int {
+	if digits, ok := exponents[strings.ToUpper(currency)]; ok {
+		return digits
+	}
+	return 2
+}
+
+// minorUnits parses a price in major units of the currency as listed by the markets, ex. "$1,234.56",
+// "1.234,56€" or "12.5", the last separator is the decimal one unless it is followed by three digits that
+// group thousands, ex. "1,234" but not "0.005" or "1,234.567", prices are rounded half up to the minor digits
+// of the currency, so "¥ 1,234" is 123400 fen in CNY and 1234 yen in JPY
+func minorUnits(s, currency string) (int64, error) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	// currencies may end with a dot, ex. "1 234,56 pуб."
+	digits = strings.TrimRight(digits, ".,")
+	if digits == "" || digits == "-" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrice, s)
+	}
+
+	minor := MinorDigits(currency)
+	whole, fraction := digits, ""
+	if i := strings.LastIndexAny(digits, ".,"); i >= 0 && !groupsThousands(digits, i, minor) {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+
+	value, err := decimalMinorUnits(whole+"."+fraction, minor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrice, s)
+	}
+	return value, nil
+}
+
+// groupsThousands reports whether the separator at i groups thousands rather than separating the decimals,
+// in currencies with three minor digits a single separator is the decimal one, ex. "1.234" dinars
+func groupsThousands(digits string, i, minor int) bool {
+	if len(digits)-i-1 != 3 {
+		return false
+	}
+	if minor == 3 && strings.Count(digits, digits[i:i+1]) == 1 {
+		return false
+	}
+	// the decimal separator comes last, another separator before it means i is the decimal one
+	if strings.ContainsRune(digits[:i], rune(otherSeparator(digits[i]))) {
+		return false
+	}
+	// a group can't lead with 0, ex. "0.005"
+	lead := strings.TrimPrefix(digits[:strings.IndexAny(digits, ".,")], "-")
+	return lead != "" && lead[0] != '0' && len(lead) <= 3
+}
+
+func otherSeparator(c byte) byte {
+	if c == '.' {
+		return ','
+	}
+	return '.'
+}
+
+// decimalMinorUnits converts a decimal number in major units, ex. "12.345" or "1.2e1", to units with the given
+// number of minor digits rounding half away from zero, so 12.345 is 1235 and -0.005 is -1 with two digits
+func decimalMinorUnits(s string, minor int) (int64, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrice, s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minor)), nil)))
+
+	num := new(big.Int).Abs(r.Num())
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if m.Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidPrice, s)
+	}
+	return q.Int64(), nil
+}
+
+// optionalMinorUnits is minorUnits for optional prices, empty strings are nil
+func optionalMinorUnits(s, currency string) (*int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	value, err := minorUnits(s, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// numberMinorUnits converts a json number in major units to minor units, the number is a plain decimal
+// unlike the prices formatted by the markets, so it never goes through the separator heuristic of minorUnits
+func numberMinorUnits(n *json.Number, currency string) (*int64, error) {
+	if n == nil {
+		return nil, nil
+	}
+	value, err := decimalMinorUnits(n.String(), MinorDigits(currency))
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// count parses a volume like "1,234"
+func count(s string) (*int32, error) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+	if digits == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(digits, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid volume %q", s)
+	}
+	v := int32(value)
+	return &v, nil
+}
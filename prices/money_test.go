@@ -0,0 +1,95 @@
+package prices
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMinorUnits(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency string
+		want     int64
+	}{
+		{"$12.34", "USD", 1234},
+		{"12,34€", "EUR", 1234},
+		{"12.5", "USD", 1250},
+		{"12", "USD", 1200},
+		{"$1,234.56", "USD", 123456},
+		{"1.234,56€", "EUR", 123456},
+		{"1 234,56 pуб.", "RUB", 123456},
+		{"1.234", "EUR", 123400},
+		{"1,234,567", "USD", 123456700},
+		// three decimals that can't group thousands
+		{"0.005", "USD", 1},
+		{"0.004", "USD", 0},
+		{"1,234.567", "USD", 123457},
+		{"1.234,565", "EUR", 123457},
+		{"12.3456", "USD", 1235},
+		{"-0.5", "USD", -50},
+		{".5", "USD", 50},
+		// the minor digits follow the currency, the yuan has fen but the yen has none
+		{"¥ 1,234", "CNY", 123400},
+		{"¥ 85.5", "CNY", 8550},
+		{"¥ 1,234", "JPY", 1234},
+		{"¥ 1,234", "jpy", 1234},
+		{"¥ 12.5", "JPY", 13},
+		{"₩ 12,345", "KRW", 12345},
+		{"1.234 KD", "KWD", 1234},
+		{"1,234.567 KD", "KWD", 1234567},
+		{"1,234,567 KD", "KWD", 1234567000},
+		{"$12.34", "XYZ", 1234},
+	}
+	for _, tt := range tests {
+		got, err := minorUnits(tt.s, tt.currency)
+		if err != nil || got != tt.want {
+			t.Errorf("minorUnits(%q, %s) = %d, %v, want %d", tt.s, tt.currency, got, err, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "-", "free", "1-2"} {
+		if got, err := minorUnits(s, "USD"); !errors.Is(err, ErrInvalidPrice) {
+			t.Errorf("minorUnits(%q) = %d, %v, want %v", s, got, err, ErrInvalidPrice)
+		}
+	}
+}
+
+func TestNumberMinorUnits(t *testing.T) {
+	tests := []struct {
+		n        json.Number
+		currency string
+		want     int64
+	}{
+		{"12.34", "EUR", 1234},
+		{"12.345", "EUR", 1235},
+		{"12.344", "EUR", 1234},
+		{"0.1234", "EUR", 12},
+		{"0.005", "EUR", 1},
+		{"1.2300000000000002", "EUR", 123},
+		{"13", "EUR", 1300},
+		{"1234", "EUR", 123400},
+		{"1.5e2", "EUR", 15000},
+		{"1E-2", "EUR", 1},
+		{"-12.345", "EUR", -1235},
+		{"85.5", "CNY", 8550},
+		{"1234", "JPY", 1234},
+		{"1234.5", "JPY", 1235},
+		{"1.2345", "KWD", 1235},
+	}
+	for _, tt := range tests {
+		got, err := numberMinorUnits(&tt.n, tt.currency)
+		if err != nil || got == nil || *got != tt.want {
+			t.Errorf("numberMinorUnits(%s, %s) = %v, %v, want %d", tt.n, tt.currency, got, err, tt.want)
+		}
+	}
+
+	if got, err := numberMinorUnits(nil, "EUR"); got != nil || err != nil {
+		t.Errorf("numberMinorUnits(nil) = %v, %v", got, err)
+	}
+	for _, n := range []json.Number{"1e30", "NaN", "1/2x"} {
+		if got, err := numberMinorUnits(&n, "EUR"); !errors.Is(err, ErrInvalidPrice) {
+			t.Errorf("numberMinorUnits(%s) = %v, %v, want %v", n, got, err, ErrInvalidPrice)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package prices
+
+import (
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+)
+
+// Observations returns the observations of an item in [from, to) ordered by time,
+// on every marketplace when marketplaceID is empty
+func (r *Repository) Observations(itemID, marketplaceID string, from, to time.Time, tx *gorm.DB) ([]models.PriceObservation, error) {
+	query := tx.Where("item_id = ? AND observed_at >= ? AND observed_at < ?", itemID, from, to)
+	if marketplaceID != "" {
+		query = query.Where("marketplace_id = ?", marketplaceID)
+	}
+
+	var observations []models.PriceObservation
+	if err := query.Order("observed_at, marketplace_id").Find(&observations).Error; err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+// MarketplaceObservations returns the observations of every item on a marketplace in [from, to) ordered by time,
+// meant for snapshots so the range should stay short
+func (r *Repository) MarketplaceObservations(marketplaceID string, from, to time.Time, tx *gorm.DB) ([]models.PriceObservation, error) {
+	var observations []models.PriceObservation
+	if err := tx.Where("marketplace_id = ? AND observed_at >= ? AND observed_at < ?", marketplaceID, from, to).
+		Order("observed_at, item_id").
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+// Latest returns the last observation of an item on each marketplace observed since the given time,
+// a zero since scans every partition
+func (r *Repository) Latest(itemID string, since time.Time, tx *gorm.DB) ([]models.PriceObservation, error) {
+	var observations []models.PriceObservation
+	if err := tx.Raw(`
+        SELECT DISTINCT ON (marketplace_id) *
+        FROM price_observations
+        WHERE item_id = ? AND observed_at >= ?
+        ORDER BY marketplace_id, observed_at DESC
+    `, itemID, since).Scan(&observations).Error; err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+// LatestPrices returns the last observation since the given time of each item on a marketplace, keyed by item id
+func (r *Repository) LatestPrices(marketplaceID string, itemIDs []string, since time.Time, tx *gorm.DB) (map[string]models.PriceObservation, error) {
+	latest := make(map[string]models.PriceObservation, len(itemIDs))
+	n := r.batchSize()
+	for start := 0; start < len(itemIDs); start += n {
+		end := min(start+n, len(itemIDs))
+
+		var observations []models.PriceObservation
+		if err := tx.Raw(`
+            SELECT DISTINCT ON (item_id) *
+            FROM price_observations
+            WHERE marketplace_id = ? AND item_id IN ? AND observed_at >= ?
+            ORDER BY item_id, observed_at DESC
+        `, marketplaceID, itemIDs[start:end], since).Scan(&observations).Error; err != nil {
+			return nil, err
+		}
+		for _, observation := range observations {
+			latest[observation.ItemID] = observation
+		}
+	}
+	return latest, nil
+}
+
+// Price is the price of an observation, its lowest ask, else its median sale, else its highest bid
+// the same price is rolled up into the candles
+func Price(o *models.PriceObservation) (int64, bool) {
+	for _, price := range []*int64{o.LowestAsk, o.MedianSale, o.HighestBid} {
+		if price != nil {
+			return *price, true
+		}
+	}
+	return 0, false
+}
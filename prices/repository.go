@@ -0,0 +1,174 @@
+// Package prices stores and queries the price observations of the items on each marketplace
+package prices
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is the number of observations per INSERT used by Record
+const DefaultBatchSize = 1000
+
+// DefaultLookback is how far before the last candles Rollup looks for late observations
+const DefaultLookback = 48 * time.Hour
+
+// columns overwritten when an observation is recorded again
+var observationColumns = []string{"currency", "lowest_ask", "highest_bid", "median_sale", "volume"}
+
+// Marketplaces known to the importers
+var Marketplaces = []models.Marketplace{
+	{ID: "steam", Name: "Steam Community Market", URL: "https://steamcommunity.com/market", Currency: "USD"},
+	{ID: "skinport", Name: "Skinport", URL: "https://skinport.com", Currency: "EUR"},
+	{ID: "csfloat", Name: "CSFloat", URL: "https://csfloat.com", Currency: "USD"},
+	{ID: "buff163", Name: "BUFF163", URL: "https://buff.163.com", Currency: "CNY"},
+}
+
+// marketplaceCurrency returns the currency of a known marketplace, empty for others
+func marketplaceCurrency(id string) string {
+	for _, m := range Marketplaces {
+		if m.ID == id {
+			return m.Currency
+		}
+	}
+	return ""
+}
+
+var ErrInvalidObservation = errors.New("invalid price observation")
+
+type Repository struct {
+	// BatchSize is the number of observations per INSERT used by Record
+	BatchSize int
+
+	// Lookback is how far before the last candles Rollup recomputes, observations recorded late with
+	// an older time are only rolled up when they fall within it
+	Lookback time.Duration
+}
+
+func NewRepository() *Repository {
+	return &Repository{BatchSize: DefaultBatchSize, Lookback: DefaultLookback}
+}
+
+func (r *Repository) batchSize() int {
+	if r.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return r.BatchSize
+}
+
+// UpsertMarketplaces creates the marketplaces or updates their name, url and currency
+func (r *Repository) UpsertMarketplaces(m []models.Marketplace, tx *gorm.DB) error {
+	if len(m) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "url", "currency"}),
+	}).Create(&m).Error
+}
+
+// partitionName is the partition holding the observations of the day, ex. price_observations_20261018
+func partitionName(day time.Time) string {
+	return "price_observations_" + day.Format("20060102")
+}
+
+// EnsurePartitions creates the daily partitions covering from to to, days are in UTC
+// nothing is cached since a partition created in a transaction that rolls back doesn't exist
+func (r *Repository) EnsurePartitions(from, to time.Time, tx *gorm.DB) error {
+	from = from.UTC().Truncate(24 * time.Hour)
+	for day := from; !day.After(to.UTC()); day = day.AddDate(0, 0, 1) {
+		name := partitionName(day)
+		if err := tx.Exec(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF price_observations FOR VALUES FROM ('%s') TO ('%s')",
+			name, day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339),
+		)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropPartitionsBefore drops the partitions of the days before t, it is the retention of the observations
+// and returns the names of the dropped partitions
+func (r *Repository) DropPartitionsBefore(t time.Time, tx *gorm.DB) ([]string, error) {
+	var names []string
+	if err := tx.Raw(`
+        SELECT c.relname FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        JOIN pg_class p ON p.oid = i.inhparent
+        WHERE p.relname = 'price_observations'
+        ORDER BY c.relname
+    `).Scan(&names).Error; err != nil {
+		return nil, err
+	}
+
+	cutoff := partitionName(t.UTC().Truncate(24 * time.Hour))
+	var dropped []string
+	for _, name := range names {
+		// names sort by day since the date is zero padded
+		if name >= cutoff {
+			break
+		}
+		if err := tx.Exec("DROP TABLE IF EXISTS " + name).Error; err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
+
+// Record stores the observations, recording an observation of the same item, marketplace and time again
+// overwrites its prices so imports can be repeated, when the slice holds it several times the last one wins
+// observation times are stored in UTC with the microsecond precision of postgres
+func (r *Repository) Record(o []models.PriceObservation, tx *gorm.DB) (int, error) {
+	type key struct {
+		item, marketplace string
+		at                time.Time
+	}
+	index := make(map[key]int, len(o))
+	observations := make([]models.PriceObservation, 0, len(o))
+	var from, to time.Time
+	for _, observation := range o {
+		if observation.ItemID == "" || observation.MarketplaceID == "" || observation.ObservedAt.IsZero() {
+			return 0, fmt.Errorf("%w: missing item, marketplace or time in %+v", ErrInvalidObservation, observation)
+		}
+		if len(observation.Currency) != 3 {
+			return 0, fmt.Errorf("%w: currency %q of %s is not an ISO 4217 code", ErrInvalidObservation, observation.Currency, observation.ItemID)
+		}
+		observation.ObservedAt = observation.ObservedAt.UTC().Truncate(time.Microsecond)
+
+		k := key{observation.ItemID, observation.MarketplaceID, observation.ObservedAt}
+		if i, ok := index[k]; ok {
+			observations[i] = observation
+			continue
+		}
+		index[k] = len(observations)
+		observations = append(observations, observation)
+
+		if from.IsZero() || observation.ObservedAt.Before(from) {
+			from = observation.ObservedAt
+		}
+		if observation.ObservedAt.After(to) {
+			to = observation.ObservedAt
+		}
+	}
+	if len(observations) == 0 {
+		return 0, nil
+	}
+
+	if err := r.EnsurePartitions(from, to, tx); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}, {Name: "marketplace_id"}, {Name: "observed_at"}},
+		DoUpdates: clause.AssignmentColumns(observationColumns),
+	}).Omit(clause.Associations).CreateInBatches(observations, r.batchSize()).Error; err != nil {
+		return 0, err
+	}
+	return len(observations), nil
+}
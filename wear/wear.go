@@ -0,0 +1,110 @@
+// Package wear maps item floats to wear tiers and checks them against the float range of skins
+package wear
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+// Tier is the float range of a wear, from Min inclusive to Max exclusive
+type Tier struct {
+	Wear models.WearType
+	Min  float64
+	Max  float64
+}
+
+// Tiers in the order of their float ranges, the last one also includes 1
+var Tiers = []Tier{
+	{models.FactoryNew, 0, 0.07},
+	{models.MinimalWear, 0.07, 0.15},
+	{models.FieldTested, 0.15, 0.38},
+	{models.WellWorn, 0.38, 0.45},
+	{models.BattleScarred, 0.45, 1},
+}
+
+var (
+	ErrInvalidFloat = errors.New("float outside of 0 to 1")
+	ErrOutOfRange   = errors.New("float outside of the range of the skin")
+)
+
+// ForFloat returns the wear of a float
+func ForFloat(f float64) (models.WearType, error) {
+	if f < 0 || f > 1 {
+		return "", fmt.Errorf("%w: %v", ErrInvalidFloat, f)
+	}
+	for _, tier := range Tiers {
+		if f < tier.Max {
+			return tier.Wear, nil
+		}
+	}
+	return Tiers[len(Tiers)-1].Wear, nil
+}
+
+// Validate checks that the float can be rolled on the skin and returns its wear
+// the range is min inclusive to max exclusive like in Reachable, skins with a fixed float only have min
+func Validate(s *models.Skin, f float64) (models.WearType, error) {
+	wear, err := ForFloat(f)
+	if err != nil {
+		return "", err
+	}
+	if !inRange(f, s.MinFloat, s.MaxFloat) {
+		return "", fmt.Errorf("%w: %v is not within [%v, %v) of %s", ErrOutOfRange, f, s.MinFloat, s.MaxFloat, s.Name)
+	}
+	return wear, nil
+}
+
+func inRange(f, min, max float64) bool {
+	if min >= max {
+		return f == min
+	}
+	return f >= min && f < max
+}
+
+// Reachable returns the wears a skin with the float range can have
+// floats are rolled from min inclusive to max exclusive, so a range ending at 0.07 is Factory New only
+func Reachable(min, max float64) []models.WearType {
+	// a fixed float, ex. of a skin that can only be rolled at 0
+	if min >= max {
+		if wear, err := ForFloat(min); err == nil {
+			return []models.WearType{wear}
+		}
+		return nil
+	}
+
+	var wears []models.WearType
+	for _, tier := range Tiers {
+		if min < tier.Max && max > tier.Min {
+			wears = append(wears, tier.Wear)
+		}
+	}
+	return wears
+}
+
+// Odds is the chance of a wear
+type Odds struct {
+	Wear models.WearType
+	Odds float64
+}
+
+// Distribution returns the chance of each wear a skin with the float range can have,
+// floats are rolled uniformly between min and max
+func Distribution(min, max float64) []Odds {
+	if min >= max {
+		var odds []Odds
+		for _, wear := range Reachable(min, max) {
+			odds = append(odds, Odds{wear, 1})
+		}
+		return odds
+	}
+
+	var odds []Odds
+	for _, tier := range Tiers {
+		if overlap := math.Min(max, tier.Max) - math.Max(min, tier.Min); overlap > 0 {
+			odds = append(odds, Odds{tier.Wear, overlap / (max - min)})
+		}
+	}
+	return odds
+}
@@ -0,0 +1,89 @@
+package wear
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/massimomarsiglia/cs-skins-market-models/models"
+)
+
+func TestValidate(t *testing.T) {
+	redline := &models.Skin{Name: "AK-47 | Redline", MinFloat: 0.1, MaxFloat: 0.7}
+	vanilla := &models.Skin{Name: "★ Karambit", MinFloat: 0, MaxFloat: 0}
+
+	tests := []struct {
+		skin *models.Skin
+		f    float64
+		want models.WearType
+		err  error
+	}{
+		{redline, 0.1, models.MinimalWear, nil},
+		{redline, 0.15, models.FieldTested, nil},
+		{redline, 0.6999, models.BattleScarred, nil},
+		{redline, 0.0999, "", ErrOutOfRange},
+		// the max is exclusive, as in Reachable
+		{redline, 0.7, "", ErrOutOfRange},
+		{redline, 1.5, "", ErrInvalidFloat},
+		{vanilla, 0, models.FactoryNew, nil},
+		{vanilla, 0.01, "", ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		got, err := Validate(tt.skin, tt.f)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("Validate(%s, %v) = %q, %v, want %q, %v", tt.skin.Name, tt.f, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// every float Validate accepts must have a wear Reachable lists for the skin
+func TestValidateAgreesWithReachable(t *testing.T) {
+	ranges := [][2]float64{{0, 0.07}, {0, 0.08}, {0.06, 0.8}, {0.1, 0.7}, {0.45, 1}, {0, 0}, {0.38, 0.45}}
+	for _, r := range ranges {
+		skin := &models.Skin{Name: "skin", MinFloat: r[0], MaxFloat: r[1]}
+		reachable := Reachable(r[0], r[1])
+		for f := 0.0; f <= 1; f += 0.005 {
+			for _, f := range []float64{f, r[0], r[1], math.Nextafter(r[1], 0)} {
+				wear, err := Validate(skin, f)
+				if err != nil {
+					continue
+				}
+				if !slices.Contains(reachable, wear) {
+					t.Errorf("%v accepted in %v as %s, reachable are %v", f, r, wear, reachable)
+				}
+			}
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		want     []models.WearType
+	}{
+		{0, 0.07, []models.WearType{models.FactoryNew}},
+		{0, 0.08, []models.WearType{models.FactoryNew, models.MinimalWear}},
+		{0.1, 0.7, []models.WearType{models.MinimalWear, models.FieldTested, models.WellWorn, models.BattleScarred}},
+		{0, 0, []models.WearType{models.FactoryNew}},
+	}
+	for _, tt := range tests {
+		if got := Reachable(tt.min, tt.max); !slices.Equal(got, tt.want) {
+			t.Errorf("Reachable(%v, %v) = %v, want %v", tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	odds := Distribution(0.1, 0.7)
+	var sum float64
+	for _, o := range odds {
+		sum += o.Odds
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("odds of %v sum to %v", odds, sum)
+	}
+	if odds[0].Wear != models.MinimalWear || math.Abs(odds[0].Odds-0.05/0.6) > 1e-9 {
+		t.Errorf("Minimal Wear odds = %+v", odds[0])
+	}
+}